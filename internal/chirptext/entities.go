@@ -0,0 +1,107 @@
+package chirptext
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Handles are 1-15 characters made up of ASCII letters, digits and underscores.
+const maxHandleLength = 15
+
+// Hashtags longer than this are ignored rather than truncated.
+const maxHashtagLength = 100
+
+// A Hashtag is a #hashtag found in a chirp body.
+// Tag is lowercased and excludes the leading '#'.
+// Start and End are rune offsets into the body; Start is inclusive and End is exclusive, and the range includes the '#'.
+type Hashtag struct {
+	Tag   string
+	Start int
+	End   int
+}
+
+// A Mention is an @handle found in a chirp body.
+// Handle is returned as written in the body (original case) and excludes the leading '@'.
+// Start and End are rune offsets into the body; Start is inclusive and End is exclusive, and the range includes the '@'.
+type Mention struct {
+	Handle string
+	Start  int
+	End    int
+}
+
+// ParseEntities scans a chirp body for #hashtags and @mentions, returning them in the order they appear.
+//
+// An entity must start the body or be preceded by a character that cannot be part of a word, so "lane@example.com" and "a#b" contain no entities.
+// Mentions longer than the maximum handle length, and hashtags made up entirely of digits, are ignored.
+func ParseEntities(body string) ([]Hashtag, []Mention) {
+	runes := []rune(body)
+
+	hashtags := []Hashtag{}
+	mentions := []Mention{}
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' && runes[i] != '@' {
+			continue
+		}
+		// entities cannot be glued onto the end of a word (or another entity)
+		if i > 0 && (isHashtagRune(runes[i-1]) || runes[i-1] == '#' || runes[i-1] == '@') {
+			continue
+		}
+
+		if runes[i] == '@' {
+			end := i + 1
+			for end < len(runes) && isHandleRune(runes[end]) {
+				end++
+			}
+			handle := string(runes[i+1 : end])
+			// a handle running straight into other word characters is not a mention
+			if IsValidHandle(handle) && (end == len(runes) || !isHashtagRune(runes[end])) {
+				mentions = append(mentions, Mention{Handle: handle, Start: i, End: end})
+			}
+			i = end - 1
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isHashtagRune(runes[end]) {
+			end++
+		}
+		tag := string(runes[i+1 : end])
+		if tag != "" && end-i-1 <= maxHashtagLength && strings.IndexFunc(tag, isNonDigit) != -1 {
+			hashtags = append(hashtags, Hashtag{Tag: strings.ToLower(tag), Start: i, End: end})
+		}
+		i = end - 1
+	}
+
+	return hashtags, mentions
+}
+
+// IsValidHandle reports whether handle (without a leading '@') is an acceptable user handle.
+func IsValidHandle(handle string) bool {
+	if len(handle) == 0 || len(handle) > maxHandleLength {
+		return false
+	}
+	for _, r := range handle {
+		if !isHandleRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeHashtag lowercases a hashtag and strips an optional leading '#', matching the form stored by ParseEntities.
+func NormalizeHashtag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+func isHandleRune(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+func isHashtagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+func isNonDigit(r rune) bool {
+	return !unicode.IsDigit(r)
+}
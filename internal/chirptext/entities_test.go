@@ -0,0 +1,68 @@
+package chirptext
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEntities(t *testing.T) {
+	cases := []struct {
+		body     string
+		hashtags []Hashtag
+		mentions []Mention
+	}{
+		{
+			body:     "hello world",
+			hashtags: []Hashtag{},
+			mentions: []Mention{},
+		},
+		{
+			body:     "loving #GoLang with @lane_w!",
+			hashtags: []Hashtag{{Tag: "golang", Start: 7, End: 14}},
+			mentions: []Mention{{Handle: "lane_w", Start: 20, End: 27}},
+		},
+		{
+			// offsets are counted in runes, not bytes
+			body:     "café #über",
+			hashtags: []Hashtag{{Tag: "über", Start: 5, End: 10}},
+			mentions: []Mention{},
+		},
+		{
+			// emails, glued-on entities, numeric tags and over-long handles are not entities
+			body:     "mail lane@example.com a#b #2024 @abcdefghijklmnopq",
+			hashtags: []Hashtag{},
+			mentions: []Mention{},
+		},
+		{
+			body:     "#one,#two @a @b",
+			hashtags: []Hashtag{{Tag: "one", Start: 0, End: 4}, {Tag: "two", Start: 5, End: 9}},
+			mentions: []Mention{{Handle: "a", Start: 10, End: 12}, {Handle: "b", Start: 13, End: 15}},
+		},
+	}
+
+	for _, c := range cases {
+		hashtags, mentions := ParseEntities(c.body)
+		if !reflect.DeepEqual(hashtags, c.hashtags) {
+			t.Errorf("ParseEntities(%q) hashtags = %+v, expected %+v", c.body, hashtags, c.hashtags)
+		}
+		if !reflect.DeepEqual(mentions, c.mentions) {
+			t.Errorf("ParseEntities(%q) mentions = %+v, expected %+v", c.body, mentions, c.mentions)
+		}
+	}
+}
+
+func TestIsValidHandle(t *testing.T) {
+	valid := []string{"a", "lane", "Lane_W", "abcdefghijklmno"}
+	invalid := []string{"", "lane!", "héllo", "abcdefghijklmnop", "@lane"}
+
+	for _, h := range valid {
+		if !IsValidHandle(h) {
+			t.Errorf("expected %q to be a valid handle", h)
+		}
+	}
+	for _, h := range invalid {
+		if IsValidHandle(h) {
+			t.Errorf("expected %q to be an invalid handle", h)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package chirptext
+
+import (
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// URLWeight is how many characters every link counts as towards a chirp's length, however long it is.
+const URLWeight = 23
+
+// Normalize puts a chirp body into Unicode Normalization Form C, so text that looks the same is stored (and counted) the same,
+// whether an "é" was typed as one code point or as an "e" followed by a combining accent.
+func Normalize(body string) string {
+	return norm.NFC.String(body)
+}
+
+// Length returns the length of a chirp body as users perceive it: the number of grapheme clusters,
+// so an emoji (even one built from several code points, like a flag or a family) or a letter with combining accents counts as one character.
+// Links found by ParseURLs count as URLWeight characters each.
+// Bodies should be normalized with Normalize first.
+func Length(body string) int {
+	runes := []rune(body)
+	length := 0
+	next := 0
+	for _, u := range ParseURLs(body) {
+		length += uniseg.GraphemeClusterCount(string(runes[next:u.Start])) + URLWeight
+		next = u.End
+	}
+	return length + uniseg.GraphemeClusterCount(string(runes[next:]))
+}
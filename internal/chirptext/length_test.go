@@ -0,0 +1,42 @@
+package chirptext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLength(t *testing.T) {
+	cases := []struct {
+		body     string
+		expected int
+	}{
+		{body: "", expected: 0},
+		{body: "hello world", expected: 11},
+		// one grapheme each, however many bytes or code points they take
+		{body: "café", expected: 4},
+		{body: "café", expected: 4},
+		{body: strings.Repeat("😀", 50), expected: 50},
+		{body: "🇦🇺👨‍👩‍👧‍👦👍🏽", expected: 3},
+		// links count as URLWeight, whatever their length
+		{body: "https://example.com", expected: URLWeight},
+		{body: "see https://example.com/a/very/long/path?with=query&and=more#fragment now", expected: 4 + URLWeight + 4},
+		{body: "(https://a.io).", expected: 1 + URLWeight + 2},
+	}
+
+	for _, c := range cases {
+		if got := Length(c.body); got != c.expected {
+			t.Errorf("Length(%q) = %d, expected %d", c.body, got, c.expected)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	decomposed := "café"
+	composed := "café"
+	if got := Normalize(decomposed); got != composed {
+		t.Errorf("Normalize(%q) = %q, expected %q", decomposed, got, composed)
+	}
+	if got := Normalize(composed); got != composed {
+		t.Errorf("Normalize(%q) = %q, expected it unchanged", composed, got)
+	}
+}
@@ -0,0 +1,82 @@
+package chirptext
+
+import (
+	"strings"
+	"unicode"
+)
+
+// A URL is an http:// or https:// link found in a chirp body.
+// Start and End are rune offsets into the body; Start is inclusive and End is exclusive.
+type URL struct {
+	URL   string
+	Start int
+	End   int
+}
+
+// ParseURLs scans a chirp body for http:// and https:// links, returning them in the order they appear.
+//
+// A link runs until the next whitespace. Trailing punctuation that usually ends a sentence rather than a URL ("." "," "!" "?" ":" ";" quotes)
+// is left out, as is a closing parenthesis without a matching opening one, so "(see https://example.com)." yields "https://example.com".
+func ParseURLs(body string) []URL {
+	runes := []rune(body)
+	urls := []URL{}
+
+	for i := 0; i < len(runes); i++ {
+		// links must start the body or follow something that can't be part of a word
+		if i > 0 && (isHashtagRune(runes[i-1]) || runes[i-1] == '/') {
+			continue
+		}
+		rest := strings.ToLower(string(runes[i:min(i+len("https://"), len(runes))]))
+		scheme := ""
+		switch {
+		case strings.HasPrefix(rest, "https://"):
+			scheme = "https://"
+		case strings.HasPrefix(rest, "http://"):
+			scheme = "http://"
+		default:
+			continue
+		}
+
+		end := i + len(scheme)
+		for end < len(runes) && !unicode.IsSpace(runes[end]) {
+			end++
+		}
+		end = trimURLEnd(runes[i:end]) + i
+		// a bare scheme with no host isn't a link
+		if end > i+len(scheme) {
+			urls = append(urls, URL{URL: string(runes[i:end]), Start: i, End: end})
+		}
+		i = end - 1
+	}
+
+	return urls
+}
+
+// trimURLEnd returns the length of a candidate link once trailing punctuation has been removed.
+func trimURLEnd(link []rune) int {
+	end := len(link)
+	for end > 0 {
+		switch link[end-1] {
+		case '.', ',', '!', '?', ':', ';', '"', '\'':
+			end--
+			continue
+		case ')':
+			// keep the parenthesis if it closes one opened inside the link, as in wikipedia links
+			opened := 0
+			for _, r := range link[:end-1] {
+				switch r {
+				case '(':
+					opened++
+				case ')':
+					opened--
+				}
+			}
+			if opened <= 0 {
+				end--
+				continue
+			}
+		}
+		break
+	}
+	return end
+}
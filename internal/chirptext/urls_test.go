@@ -0,0 +1,44 @@
+package chirptext
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseURLs(t *testing.T) {
+	cases := []struct {
+		body string
+		urls []URL
+	}{
+		{
+			body: "no links here, not even example.com",
+			urls: []URL{},
+		},
+		{
+			body: "read https://example.com/a?b=c#d now",
+			urls: []URL{{URL: "https://example.com/a?b=c#d", Start: 5, End: 32}},
+		},
+		{
+			// trailing punctuation and unmatched parentheses are not part of the link
+			body: "(see http://example.com/x). HTTPS://Example.com!",
+			urls: []URL{{URL: "http://example.com/x", Start: 5, End: 25}, {URL: "HTTPS://Example.com", Start: 28, End: 47}},
+		},
+		{
+			// parentheses opened inside the link are kept
+			body: "https://en.wikipedia.org/wiki/Go_(language)",
+			urls: []URL{{URL: "https://en.wikipedia.org/wiki/Go_(language)", Start: 0, End: 43}},
+		},
+		{
+			// offsets are counted in runes, and a bare scheme or a scheme glued onto a word is not a link
+			body: "café https://café.fr xhttps://a.b https://",
+			urls: []URL{{URL: "https://café.fr", Start: 5, End: 20}},
+		},
+	}
+
+	for _, c := range cases {
+		urls := ParseURLs(c.body)
+		if !reflect.DeepEqual(urls, c.urls) {
+			t.Errorf("ParseURLs(%q) = %+v, expected %+v", c.body, urls, c.urls)
+		}
+	}
+}
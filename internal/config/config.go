@@ -1,20 +1,42 @@
 package config
 
 import (
+	"database/sql"
 	"net/http"
+	"net/netip"
 	"sync/atomic"
 
+	"github.com/google/uuid"
 	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/linkpreview"
+	"github.com/rickNoise/chirpy/internal/media"
+	"github.com/rickNoise/chirpy/internal/spam"
+	"github.com/rickNoise/chirpy/internal/stream"
 )
 
 const maxChirpLength = 140
 
+// maximum number of media items attached to a single chirp
+const maxChirpMedia = 4
+
 type ApiConfig struct {
-	fileserverHits atomic.Int32
-	DbQueries      *database.Queries
-	Platform       string
-	JWTSecret      string
-	PolkaKey       string
+	fileserverHits   atomic.Int32
+	DbQueries        *database.Queries
+	DB               *sql.DB // used to begin transactions spanning several queries
+	Platform         string
+	JWTSecret        string
+	PolkaKey         string
+	Stream           *stream.Hub          // fans out real-time chirp events
+	Broadcaster      *stream.PGBridge     // optional; relays events between instances
+	Blobs            media.BlobStore      // stores the bytes of uploaded media
+	MediaQueue       chan uuid.UUID       // ids of uploaded media waiting to be processed
+	LinkPreviews     *linkpreview.Fetcher // fetches the preview cards of links in chirps
+	LinkPreviewQueue chan string          // links waiting to have their preview fetched
+	ChirpSpam        *spam.Pipeline       // optional; scores new chirps
+	SignupSpam       *spam.Pipeline       // optional; scores new accounts
+	AdminKey         string               // authorises the admin moderation and audit endpoints; they are disabled when empty
+	AuditHashKey     string               // optional; keys the hashes identifying email addresses in audit events
+	TrustedProxies   []netip.Prefix       // load balancers whose X-Forwarded-For header is believed when recording client addresses
 }
 
 func (cfg *ApiConfig) MiddlewareMetricsInc(next http.Handler) http.Handler {
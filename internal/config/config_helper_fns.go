@@ -3,12 +3,12 @@ package config
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
-	"log"
 	"net/http"
 	"strings"
 
 	"github.com/lib/pq"
+	"github.com/rickNoise/chirpy/internal/chirptext"
+	"github.com/rickNoise/chirpy/internal/logging"
 )
 
 /* HELPER FUNCTIONS */
@@ -74,13 +74,13 @@ func censorChirp(body string) (bool, string) {
 	return anyCensorDone, strings.Join(censoredBody, " ")
 }
 
-// msg is returned to the requester; err is logged internally
+// msg is returned to the requester; err is logged internally with the request's id, at error level for 5XX responses
 func respondWithError(w http.ResponseWriter, code int, msg string, err error) {
-	if err != nil {
-		fmt.Println(err)
-	}
+	logger := logging.FromResponseWriter(w)
 	if code > 499 {
-		log.Printf("Responding with 5XX error: %s", err)
+		logger.Error("responding with server error", "status", code, "message", msg, "error", err)
+	} else if err != nil {
+		logger.Info("responding with client error", "status", code, "message", msg, "error", err)
 	}
 	type errorResponse struct {
 		Error string `json:"error"`
@@ -94,7 +94,7 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	dat, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Error marshalling JSON: %s", err)
+		logging.FromResponseWriter(w).Error("could not marshal JSON response", "error", err)
 		w.WriteHeader(500)
 		return
 	}
@@ -102,6 +102,18 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Write(dat)
 }
 
+// validateChirpBody checks that a chirp body can be published; the error message is suitable for the requester.
+// The body should already be normalized with chirptext.Normalize, and its length is counted with chirptext.Length.
+func validateChirpBody(body string) error {
+	if chirptext.Length(body) > maxChirpLength {
+		return errors.New("Chirp is too long")
+	}
+	if len(body) == 0 {
+		return errors.New("Chirp cannot have an empty body")
+	}
+	return nil
+}
+
 func validatePassword(password string) bool {
 	return len(password) != 0
 }
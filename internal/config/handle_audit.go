@@ -0,0 +1,174 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/logging"
+)
+
+// number of audit events loaded at a time while exporting
+const auditExportBatchSize = 500
+
+// An AuditEvent is a security-sensitive action recorded in the audit log.
+type AuditEvent struct {
+	ID        uuid.UUID       `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	EventType string          `json:"event_type"`
+	Outcome   string          `json:"outcome"`
+	ActorID   *uuid.UUID      `json:"actor_id"`
+	TargetID  *uuid.UUID      `json:"target_id"`
+	IPAddress string          `json:"ip_address"`
+	UserAgent string          `json:"user_agent"`
+	RequestID string          `json:"request_id"`
+	Details   json.RawMessage `json:"details"`
+}
+
+// A page of audit events. NextCursor is set when there may be more events; pass it as ?cursor= to get the next page.
+type AuditEventPage struct {
+	Events     []AuditEvent `json:"events"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+func databaseAuditEventToAPIAuditEvent(e database.AuditEvent) AuditEvent {
+	event := AuditEvent{
+		ID:        e.ID,
+		CreatedAt: e.CreatedAt,
+		EventType: e.EventType,
+		Outcome:   e.Outcome,
+		IPAddress: e.IpAddress,
+		UserAgent: e.UserAgent,
+		RequestID: e.RequestID,
+		Details:   e.Details,
+	}
+	if e.ActorID.Valid {
+		event.ActorID = &e.ActorID.UUID
+	}
+	if e.TargetID.Valid {
+		event.TargetID = &e.TargetID.UUID
+	}
+	return event
+}
+
+// GET /admin/audit lists the audit log, newest first (paginated). Requires the ADMIN_KEY in an "Authorization: ApiKey <key>" header.
+// Events can be filtered with the optional event_type (e.g. "auth.login"), outcome ("success" or "failure"), actor_id, target_id,
+// since and until (RFC 3339 timestamps) query parameters.
+// With ?format=jsonl every matching event is downloaded instead, as one JSON object per line.
+func (cfg *ApiConfig) HandleGetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	if !cfg.authenticateAdmin(w, r) {
+		return // helper already wrote the error response
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "jsonl" {
+		respondWithError(w, http.StatusBadRequest, "format must be json or jsonl", nil)
+		return
+	}
+
+	limit, cursor, ok := parsePageRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	filter := database.GetAuditEventsPageParams{
+		EventType: query.Get("event_type"),
+		Outcome:   query.Get("outcome"),
+		Until:     firstPageCursor.time,
+	}
+	if filter.Outcome != "" && filter.Outcome != auditSuccess && filter.Outcome != auditFailure {
+		respondWithError(w, http.StatusBadRequest, "outcome must be success or failure", nil)
+		return
+	}
+	for name, id := range map[string]*uuid.UUID{"actor_id": &filter.ActorID, "target_id": &filter.TargetID} {
+		if raw := query.Get(name); raw != "" {
+			parsed, err := uuid.Parse(raw)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "invalid "+name, err)
+				return
+			}
+			*id = parsed
+		}
+	}
+	for name, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if raw := query.Get(name); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, name+" must be an RFC 3339 timestamp", err)
+				return
+			}
+			*t = parsed.UTC()
+		}
+	}
+
+	if format == "jsonl" {
+		cfg.exportAuditEvents(w, r, filter)
+		return
+	}
+
+	filter.BeforeCreatedAt = cursor.time
+	filter.BeforeID = cursor.id
+	filter.MaxItems = limit
+	dbEvents, err := cfg.DbQueries.GetAuditEventsPage(r.Context(), filter)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get audit events", err)
+		return
+	}
+
+	page := AuditEventPage{Events: make([]AuditEvent, 0, len(dbEvents))}
+	for _, e := range dbEvents {
+		page.Events = append(page.Events, databaseAuditEventToAPIAuditEvent(e))
+	}
+	if len(dbEvents) == int(limit) {
+		last := dbEvents[len(dbEvents)-1]
+		page.NextCursor = pageCursor{time: last.CreatedAt, id: last.ID}.String()
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// exportAuditEvents streams every audit event matching the filter as JSON Lines, newest first, loading them in batches.
+// Once the response has started an error can't be reported with a status code, so the export just stops early.
+func (cfg *ApiConfig) exportAuditEvents(w http.ResponseWriter, r *http.Request, filter database.GetAuditEventsPageParams) {
+	filter.BeforeCreatedAt = firstPageCursor.time
+	filter.BeforeID = firstPageCursor.id
+	filter.MaxItems = auditExportBatchSize
+
+	dbEvents, err := cfg.DbQueries.GetAuditEventsPage(r.Context(), filter)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export audit events", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit-events.jsonl"`)
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	for len(dbEvents) > 0 {
+		for _, e := range dbEvents {
+			if err := encoder.Encode(databaseAuditEventToAPIAuditEvent(e)); err != nil {
+				logging.FromContext(r.Context()).Warn("could not write audit export", "error", err)
+				return
+			}
+		}
+		if len(dbEvents) < auditExportBatchSize {
+			return
+		}
+
+		last := dbEvents[len(dbEvents)-1]
+		filter.BeforeCreatedAt = last.CreatedAt
+		filter.BeforeID = last.ID
+		dbEvents, err = cfg.DbQueries.GetAuditEventsPage(r.Context(), filter)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("could not export audit events", "error", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+)
+
+// POST /api/users/{userID}/block blocks a user for the authenticated user.
+// The two users no longer see each other's chirps or follow each other, and the blocked user's mentions of the blocker are dropped. Blocking an already blocked user is a no-op.
+// On success it responds with a 204 status code.
+func (cfg *ApiConfig) HandleBlockUser(w http.ResponseWriter, r *http.Request) {
+	blockerID, blockedID, ok := cfg.parseUserRelationshipRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	// blocking also ends any follows (or follow requests) between the two users
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not block user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	err = qtx.CreateBlock(r.Context(), database.CreateBlockParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		if checkForForeignKeyConstraintViolationPostgresql(err) {
+			respondWithError(w, http.StatusNotFound, "user not found", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not block user", err)
+		}
+		return
+	}
+	err = qtx.DeleteFollowsBetween(r.Context(), database.DeleteFollowsBetweenParams{
+		UserID:      blockerID,
+		OtherUserID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not block user", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not block user", err)
+		return
+	}
+	cfg.publishRelationshipsChanged(r.Context(), blockerID, blockedID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /api/users/{userID}/block unblocks a user. It responds with a 204 status code, or 404 if the user wasn't blocked.
+func (cfg *ApiConfig) HandleUnblockUser(w http.ResponseWriter, r *http.Request) {
+	blockerID, blockedID, ok := cfg.parseUserRelationshipRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	rows, err := cfg.DbQueries.DeleteBlock(r.Context(), database.DeleteBlockParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not unblock user", err)
+		return
+	}
+	if rows == 0 {
+		respondWithError(w, http.StatusNotFound, "user is not blocked", nil)
+		return
+	}
+	cfg.publishRelationshipsChanged(r.Context(), blockerID, blockedID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/users/me/blocks lists the users the authenticated user has blocked, most recent first.
+func (cfg *ApiConfig) HandleGetBlocks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbBlocks, err := cfg.DbQueries.GetBlocksByUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get blocks", err)
+		return
+	}
+
+	relationships := make([]userRelationship, 0, len(dbBlocks))
+	for _, b := range dbBlocks {
+		relationships = append(relationships, userRelationship{userID: b.BlockedID, createdAt: b.CreatedAt})
+	}
+	jsonRelationships, err := cfg.userRelationshipsToAPI(r.Context(), relationships)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get blocks", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jsonRelationships)
+}
+
+// parseUserRelationshipRequest authenticates the acting user and parses the {userID} they are acting on, which must be someone else.
+func (cfg *ApiConfig) parseUserRelationshipRequest(w http.ResponseWriter, r *http.Request) (actingUserID, targetUserID uuid.UUID, ok bool) {
+	actingUserID, ok = cfg.authenticateUser(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	targetUserID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if targetUserID == actingUserID {
+		respondWithError(w, http.StatusBadRequest, "cannot do that to yourself", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return actingUserID, targetUserID, true
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+)
+
+// A page of chirps. NextCursor is set when there may be more chirps; pass it as ?cursor= to get the next page.
+type ChirpPage struct {
+	Chirps     []Chirp `json:"chirps"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// POST /api/chirps/{chirpID}/bookmark privately bookmarks a chirp for the authenticated user.
+// Bookmarking an already bookmarked chirp is a no-op. It responds with a 204 status code, or 404 if the chirp doesn't exist or the user can't see it.
+func (cfg *ApiConfig) HandleBookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	userID, dbChirp, ok := cfg.parseVisibleChirpRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	err := cfg.DbQueries.CreateBookmark(r.Context(), database.CreateBookmarkParams{
+		UserID:  userID,
+		ChirpID: dbChirp.ID,
+	})
+	if err != nil {
+		if checkForForeignKeyConstraintViolationPostgresql(err) {
+			respondWithError(w, http.StatusNotFound, "chirp not found", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not bookmark chirp", err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /api/chirps/{chirpID}/bookmark removes a bookmark. It responds with a 204 status code, or 404 if the chirp wasn't bookmarked.
+func (cfg *ApiConfig) HandleUnbookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id", err)
+		return
+	}
+
+	rows, err := cfg.DbQueries.DeleteBookmark(r.Context(), database.DeleteBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not remove bookmark", err)
+		return
+	}
+	if rows == 0 {
+		respondWithError(w, http.StatusNotFound, "chirp is not bookmarked", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/bookmarks lists the authenticated user's bookmarked chirps, most recently bookmarked first.
+// It returns a page of up to ?limit= chirps (default 20, at most 100) along with a next_cursor for the following page.
+// Bookmarks of deleted chirps are hidden (and come back if the chirp is restored), and are removed when the chirp is purged.
+func (cfg *ApiConfig) HandleGetBookmarks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	limit, cursor, ok := parsePageRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbBookmarks, err := cfg.DbQueries.GetBookmarksPage(r.Context(), database.GetBookmarksPageParams{
+		UserID:          userID,
+		BeforeCreatedAt: cursor.time,
+		BeforeChirpID:   cursor.id,
+		MaxBookmarks:    limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get bookmarks", err)
+		return
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(dbBookmarks))
+	for _, b := range dbBookmarks {
+		chirpIDs = append(chirpIDs, b.ChirpID)
+	}
+	jsonChirps, err := cfg.visibleChirpsInOrder(r.Context(), userID, chirpIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get bookmarks", err)
+		return
+	}
+
+	page := ChirpPage{Chirps: jsonChirps}
+	// a short page is the last one; hidden chirps don't count, so a page can hold fewer chirps than the limit and still have a next page
+	if len(dbBookmarks) == int(limit) {
+		last := dbBookmarks[len(dbBookmarks)-1]
+		page.NextCursor = pageCursor{time: last.CreatedAt, id: last.ChirpID}.String()
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
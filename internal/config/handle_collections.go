@@ -0,0 +1,401 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+)
+
+// maximum lengths of the name and description of collections and user lists, in characters
+const maxListNameLength = 50
+const maxListDescriptionLength = 160
+
+// POST /api/collections creates a named collection of chirps for the authenticated user.
+//
+//	{"name": "Recipes", "description": "things to cook", "is_public": true}
+//
+// Collections are private unless is_public is set. A user's collection names must be unique; a duplicate results in a 409 status code.
+// On success it responds with a 201 status code and the collection.
+func (cfg *ApiConfig) HandleCreateCollection(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	type parameters struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		IsPublic    bool   `json:"is_public"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "error decoding req json body", err)
+		return
+	}
+	name, description, msg := validateListFields(params.Name, params.Description)
+	if msg != "" {
+		respondWithError(w, http.StatusBadRequest, msg, nil)
+		return
+	}
+
+	dbCollection, err := cfg.DbQueries.CreateCollection(r.Context(), database.CreateCollectionParams{
+		UserID:      userID,
+		Name:        name,
+		Description: description,
+		IsPublic:    params.IsPublic,
+	})
+	if err != nil {
+		if checkForUniqueConstraintViolationPostgresql(err) {
+			respondWithError(w, http.StatusConflict, "you already have a collection with that name", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not create collection", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, DatabaseCollectionToAPICollection(dbCollection))
+}
+
+// GET /api/collections lists the authenticated user's collections, public and private, in alphabetical order.
+func (cfg *ApiConfig) HandleGetCollections(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbCollections, err := cfg.DbQueries.GetCollectionsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get collections", err)
+		return
+	}
+
+	jsonCollections := make([]Collection, 0, len(dbCollections))
+	for _, c := range dbCollections {
+		jsonCollections = append(jsonCollections, DatabaseCollectionToAPICollection(c))
+	}
+	respondWithJSON(w, http.StatusOK, jsonCollections)
+}
+
+// GET /api/collections/{collectionID} returns a collection. Public collections can be viewed by anyone; private ones respond with a 404 status code to anyone but their owner.
+func (cfg *ApiConfig) HandleGetCollection(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := cfg.authenticateOptionalUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	dbCollection, ok := cfg.loadCollection(w, r, viewerID, false)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	respondWithJSON(w, http.StatusOK, DatabaseCollectionToAPICollection(dbCollection))
+}
+
+// PATCH /api/collections/{collectionID} updates any of the name, description and is_public of one of the authenticated user's collections; omitted fields are left unchanged.
+// It responds with a 200 status code and the updated collection.
+func (cfg *ApiConfig) HandleUpdateCollection(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	dbCollection, ok := cfg.loadCollection(w, r, userID, true)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	type parameters struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		IsPublic    *bool   `json:"is_public"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "error decoding req json body", err)
+		return
+	}
+
+	update := database.UpdateCollectionParams{
+		Name:        dbCollection.Name,
+		Description: dbCollection.Description,
+		IsPublic:    dbCollection.IsPublic,
+		ID:          dbCollection.ID,
+	}
+	if params.Name != nil {
+		update.Name = *params.Name
+	}
+	if params.Description != nil {
+		update.Description = *params.Description
+	}
+	if params.IsPublic != nil {
+		update.IsPublic = *params.IsPublic
+	}
+	var msg string
+	update.Name, update.Description, msg = validateListFields(update.Name, update.Description)
+	if msg != "" {
+		respondWithError(w, http.StatusBadRequest, msg, nil)
+		return
+	}
+
+	dbUpdatedCollection, err := cfg.DbQueries.UpdateCollection(r.Context(), update)
+	if err != nil {
+		if checkForUniqueConstraintViolationPostgresql(err) {
+			respondWithError(w, http.StatusConflict, "you already have a collection with that name", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not update collection", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, DatabaseCollectionToAPICollection(dbUpdatedCollection))
+}
+
+// DELETE /api/collections/{collectionID} deletes one of the authenticated user's collections; the chirps in it are untouched.
+// It responds with a 204 status code.
+func (cfg *ApiConfig) HandleDeleteCollection(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	dbCollection, ok := cfg.loadCollection(w, r, userID, true)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	if err := cfg.DbQueries.DeleteCollection(r.Context(), dbCollection.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not delete collection", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/collections/{collectionID}/chirps lists the chirps in a collection, in the collection's order.
+// The collection must be visible to the viewer, and chirps the viewer isn't allowed to see are left out, as are deleted chirps (until they are restored).
+func (cfg *ApiConfig) HandleGetCollectionChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := cfg.authenticateOptionalUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	dbCollection, ok := cfg.loadCollection(w, r, viewerID, false)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	chirpIDs, err := cfg.DbQueries.GetCollectionChirpIDs(r.Context(), dbCollection.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get collection chirps", err)
+		return
+	}
+	jsonChirps, err := cfg.visibleChirpsInOrder(r.Context(), viewerID, chirpIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get collection chirps", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jsonChirps)
+}
+
+// POST /api/collections/{collectionID}/chirps adds a chirp, given as {"chirp_id": "..."}, to the end of one of the authenticated user's collections.
+// Adding a chirp that is already in the collection is a no-op. It responds with a 204 status code, or 404 if the chirp doesn't exist or the user can't see it.
+func (cfg *ApiConfig) HandleAddChirpToCollection(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	dbCollection, ok := cfg.loadCollection(w, r, userID, true)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	type parameters struct {
+		ChirpID uuid.UUID `json:"chirp_id"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "error decoding req json body", err)
+		return
+	}
+
+	dbChirp, err := cfg.DbQueries.GetChirp(r.Context(), params.ChirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "chirp not found", err)
+		return
+	}
+	visibleChirps, err := cfg.filterChirpsForViewer(r.Context(), userID, []database.Chirp{dbChirp}, false)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not add chirp to collection", err)
+		return
+	}
+	if len(visibleChirps) == 0 {
+		respondWithError(w, http.StatusNotFound, "chirp not found", nil)
+		return
+	}
+
+	_, err = cfg.DbQueries.AddChirpToCollection(r.Context(), database.AddChirpToCollectionParams{
+		CollectionID: dbCollection.ID,
+		ChirpID:      dbChirp.ID,
+	})
+	if err != nil {
+		if checkForForeignKeyConstraintViolationPostgresql(err) {
+			respondWithError(w, http.StatusNotFound, "chirp not found", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not add chirp to collection", err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /api/collections/{collectionID}/chirps/{chirpID} removes a chirp from one of the authenticated user's collections.
+// It responds with a 204 status code, or 404 if the chirp wasn't in the collection.
+func (cfg *ApiConfig) HandleRemoveChirpFromCollection(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	dbCollection, ok := cfg.loadCollection(w, r, userID, true)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id", err)
+		return
+	}
+
+	rows, err := cfg.DbQueries.RemoveChirpFromCollection(r.Context(), database.RemoveChirpFromCollectionParams{
+		CollectionID: dbCollection.ID,
+		ChirpID:      chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not remove chirp from collection", err)
+		return
+	}
+	if rows == 0 {
+		respondWithError(w, http.StatusNotFound, "chirp is not in the collection", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PUT /api/collections/{collectionID}/chirps reorders one of the authenticated user's collections.
+//
+//	{"chirp_ids": ["<first>", "<second>", ...]}
+//
+// The listed chirps, which must already be in the collection, move to the front in the given order; any chirps not listed (e.g. ones hidden from the owner) keep their relative order after them.
+// It responds with a 204 status code.
+func (cfg *ApiConfig) HandleReorderCollection(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	dbCollection, ok := cfg.loadCollection(w, r, userID, true)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	type parameters struct {
+		ChirpIDs []uuid.UUID `json:"chirp_ids"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "error decoding req json body", err)
+		return
+	}
+
+	// reordering reads and rewrites every position, so it happens in one transaction
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not reorder collection", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	currentIDs, err := qtx.GetCollectionChirpIDs(r.Context(), dbCollection.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not reorder collection", err)
+		return
+	}
+	for i, id := range params.ChirpIDs {
+		if !slices.Contains(currentIDs, id) {
+			respondWithError(w, http.StatusBadRequest, "chirp is not in the collection: "+id.String(), nil)
+			return
+		}
+		if slices.Contains(params.ChirpIDs[:i], id) {
+			respondWithError(w, http.StatusBadRequest, "duplicate chirp id", nil)
+			return
+		}
+	}
+
+	newOrder := slices.Clone(params.ChirpIDs)
+	for _, id := range currentIDs {
+		if !slices.Contains(params.ChirpIDs, id) {
+			newOrder = append(newOrder, id)
+		}
+	}
+	for position, id := range newOrder {
+		err := qtx.SetCollectionChirpPosition(r.Context(), database.SetCollectionChirpPositionParams{
+			Position:     int32(position),
+			CollectionID: dbCollection.ID,
+			ChirpID:      id,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not reorder collection", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not reorder collection", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadCollection loads the {collectionID} collection, writing an error response if it isn't visible to the viewer,
+// or if requireOwner is set and the viewer doesn't own it.
+func (cfg *ApiConfig) loadCollection(w http.ResponseWriter, r *http.Request, viewerID uuid.UUID, requireOwner bool) (database.Collection, bool) {
+	collectionID, err := uuid.Parse(r.PathValue("collectionID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid collection id", err)
+		return database.Collection{}, false
+	}
+
+	dbCollection, err := cfg.DbQueries.GetCollection(r.Context(), collectionID)
+	if err != nil || (!dbCollection.IsPublic && dbCollection.UserID != viewerID) {
+		respondWithError(w, http.StatusNotFound, "collection not found", err)
+		return database.Collection{}, false
+	}
+	if requireOwner && dbCollection.UserID != viewerID {
+		respondWithError(w, http.StatusForbidden, "you can only change your own collections", nil)
+		return database.Collection{}, false
+	}
+
+	return dbCollection, true
+}
+
+// validateListFields trims and checks the name and description of a collection or user list, returning a message for the requester if they are invalid.
+func validateListFields(name, description string) (string, string, string) {
+	name = strings.TrimSpace(name)
+	description = strings.TrimSpace(description)
+	if name == "" {
+		return name, description, "name is required"
+	}
+	if utf8.RuneCountInString(name) > maxListNameLength {
+		return name, description, fmt.Sprintf("name must be at most %d characters", maxListNameLength)
+	}
+	if utf8.RuneCountInString(description) > maxListDescriptionLength {
+		return name, description, fmt.Sprintf("description must be at most %d characters", maxListDescriptionLength)
+	}
+	return name, description, ""
+}
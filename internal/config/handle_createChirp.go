@@ -1,17 +1,32 @@
 package config
 
 import (
-	"context"
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"slices"
+	"time"
 
-	"github.com/rickNoise/chirpy/internal/auth"
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/chirptext"
 	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/stream"
 )
 
+// POST /api/chirps creates a chirp for the authenticated user.
+// The optional visibility decides who can see it: "public" (the default), "followers" (approved followers only), "mentioned" (only the users it @mentions) or "unlisted" (anyone with a link, but left out of timelines, search and trends).
+// With an optional publish_at in the future, the chirp is scheduled: it stays hidden until the scheduler publishes it (see GET /api/scheduled-chirps).
+// Chirpy Red users can attach a poll with 2 to 4 options, open for duration_minutes after the chirp is published; users vote with POST /api/chirps/{chirpID}/vote.
+// Every chirp is scored by the spam pipeline: likely spam is rejected with a 400 status code, and suspicious chirps are created but hidden from everyone
+// except their author, either until a moderator approves them or for good (see GET /admin/moderation/chirps).
 func (cfg *ApiConfig) HandleCreateChirp(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body string `json:"body"`
+		Body       string          `json:"body"`
+		MediaIDs   []uuid.UUID     `json:"media_ids"`  // optional; previously uploaded via POST /api/media
+		Visibility string          `json:"visibility"` // optional; defaults to public
+		PublishAt  *time.Time      `json:"publish_at"` // optional; schedules the chirp instead of publishing it now
+		Poll       *pollParameters `json:"poll"`       // optional; Chirpy Red only
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -22,36 +37,93 @@ func (cfg *ApiConfig) HandleCreateChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// check request for valid Authorization header
-	bearerToken, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "no valid bearer token in request", err)
+	// determine posting user by JWT
+	parsedUserId, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	// check length of chirp body, which is stored normalized
+	params.Body = chirptext.Normalize(params.Body)
+	if err := validateChirpBody(params.Body); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
-	// determine posting user by JWT
-	parsedUserId, err := auth.ValidateJWT(bearerToken, cfg.JWTSecret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "invalid user bearer token", err)
+	// check attached media
+	if len(params.MediaIDs) > maxChirpMedia {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("a chirp can have at most %d media attachments", maxChirpMedia), nil)
 		return
 	}
+	for i, id := range params.MediaIDs {
+		if slices.Contains(params.MediaIDs[:i], id) {
+			respondWithError(w, http.StatusBadRequest, "duplicate media id", nil)
+			return
+		}
+	}
 
-	// check length of chirp body
-	if len(params.Body) > maxChirpLength {
-		respondWithError(w, http.StatusBadRequest, "Chirp is too long", nil)
+	// check requested visibility
+	if params.Visibility == "" {
+		params.Visibility = chirpVisibilityPublic
+	}
+	if !slices.Contains(chirpVisibilities, params.Visibility) {
+		respondWithError(w, http.StatusBadRequest, "visibility must be one of public, followers, mentioned or unlisted", nil)
 		return
 	}
-	if len(params.Body) == 0 {
-		respondWithError(w, http.StatusBadRequest, "Chirp cannot have an empty body", nil)
+
+	// check requested publish time
+	publishAt := sql.NullTime{}
+	if params.PublishAt != nil {
+		if err := validateChirpPublishAt(*params.PublishAt); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		publishAt = sql.NullTime{Time: params.PublishAt.UTC(), Valid: true}
+	}
+
+	dbUser, err := cfg.DbQueries.GetUserById(r.Context(), parsedUserId)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
 		return
 	}
 
+	// check requested poll, which only Chirpy Red users can attach
+	var pollOptions []string
+	if params.Poll != nil {
+		pollOptions, err = validatePoll(*params.Poll)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		if !dbUser.IsChirpyRed {
+			respondWithError(w, http.StatusForbidden, "polls are a Chirpy Red feature", nil)
+			return
+		}
+	}
+
 	// check if chirp body requires censoring (still valid)
 	_, censoredBody := censorChirp(params.Body)
 
-	dbChirp, err := cfg.DbQueries.CreateChirp(context.Background(), database.CreateChirpParams{
-		Body:   censoredBody,
-		UserID: parsedUserId,
+	// score the chirp as it will be stored, so duplicates are compared against stored bodies
+	verdict, ok := cfg.checkChirpSpam(w, r, dbUser, censoredBody)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	// the chirp, its parsed entities, its media, its poll and any moderation flag are stored together in a single transaction
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not add chirp to database", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	dbChirp, err := qtx.CreateChirp(r.Context(), database.CreateChirpParams{
+		Body:       censoredBody,
+		UserID:     parsedUserId,
+		Visibility: params.Visibility,
+		PublishAt:  publishAt,
 	})
 	if err != nil {
 		if checkForForeignKeyConstraintViolationPostgresql(err) {
@@ -62,6 +134,59 @@ func (cfg *ApiConfig) HandleCreateChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// extract mentions and hashtags from the censored body
+	if err := storeChirpEntities(r.Context(), qtx, dbChirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not add chirp to database", err)
+		return
+	}
+
+	// attach media, which must belong to the posting user and not already be attached to another chirp
+	for i, mediaID := range params.MediaIDs {
+		attached, err := qtx.AttachMediaToChirp(r.Context(), database.AttachMediaToChirpParams{
+			ChirpID:  uuid.NullUUID{UUID: dbChirp.ID, Valid: true},
+			Position: sql.NullInt32{Int32: int32(i), Valid: true},
+			ID:       mediaID,
+			UserID:   parsedUserId,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not attach media", err)
+			return
+		}
+		if attached == 0 {
+			respondWithError(w, http.StatusBadRequest, "media not found or already attached: "+mediaID.String(), nil)
+			return
+		}
+	}
+
+	if params.Poll != nil {
+		if err := storeChirpPoll(r.Context(), qtx, dbChirp.ID, pollOptions, params.Poll.DurationMinutes); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not add chirp to database", err)
+			return
+		}
+	}
+
+	if err := flagChirp(r.Context(), qtx, dbChirp.ID, verdict); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not add chirp to database", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not add chirp to database", err)
+		return
+	}
+	cfg.enqueueChirpLinkPreview(dbChirp)
+
+	jsonChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), parsedUserId, []database.Chirp{dbChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not load chirp entities", err)
+		return
+	}
+
+	// notify real-time subscribers; scheduled chirps are announced by the scheduler once they are published, and chirps hidden by moderation aren't announced
+	if !dbChirp.PublishAt.Valid {
+		cfg.publishChirpEvent(r.Context(), stream.ChirpCreated, dbChirp, jsonChirps[0])
+	}
+
 	// If creating the record succeeds, respond with a 201 status code and the full chirp resource
-	respondWithJSON(w, http.StatusCreated, DatabaseChirpToAPIChirp(dbChirp))
+	respondWithJSON(w, http.StatusCreated, jsonChirps[0])
 }
@@ -1,17 +1,24 @@
 package config
 
 import (
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/rickNoise/chirpy/internal/auth"
+	"github.com/rickNoise/chirpy/internal/chirptext"
 	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/spam"
 )
 
+// POST /api/users creates an account. Every signup is scored by the spam pipeline: likely spam is rejected with a 403 status code,
+// and suspicious accounts are created but have their chirps hidden from everyone else until a moderator approves them (see GET /admin/moderation/users).
 func (cfg *ApiConfig) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Password string `json:"password"`
 		Email    string `json:"email"`
+		Handle   string `json:"handle"` // optional
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -27,24 +34,68 @@ func (cfg *ApiConfig) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// handles are optional, but must be well-formed if provided
+	handle := sql.NullString{}
+	if params.Handle != "" {
+		if !chirptext.IsValidHandle(params.Handle) {
+			respondWithError(w, http.StatusBadRequest, "invalid handle provided; use 1-15 letters, digits or underscores", nil)
+			return
+		}
+		handle = sql.NullString{String: params.Handle, Valid: true}
+	}
+
 	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "could not create user", err)
 		return
 	}
 
-	dbUser, err := cfg.DbQueries.CreateUser(r.Context(), database.CreateUserParams{
+	verdict := checkSpam(r.Context(), cfg.SignupSpam, spam.Subject{Email: params.Email})
+	if verdict.Action == spam.ActionReject {
+		respondWithError(w, http.StatusForbidden, "signup rejected as likely spam", fmt.Errorf("spam score %.2f: %s", verdict.Score, verdict.Reasons()))
+		return
+	}
+
+	// the user and any moderation flag are stored together in a single transaction
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not create user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	dbUser, err := qtx.CreateUser(r.Context(), database.CreateUserParams{
 		Email:          params.Email,
 		Hashedpassword: hashedPassword,
+		Handle:         handle,
 	})
 	if err != nil {
 		if checkForUniqueConstraintViolationPostgresql(err) {
-			respondWithError(w, http.StatusConflict, "user already exists", err)
+			respondWithError(w, http.StatusConflict, "user with that email or handle already exists", err)
 		} else {
 			respondWithError(w, http.StatusInternalServerError, "could not create user", err)
 		}
 		return
 	}
 
+	if status := moderationStatus(verdict); status != "" {
+		err := qtx.CreateUserModeration(r.Context(), database.CreateUserModerationParams{
+			UserID:  dbUser.ID,
+			Status:  status,
+			Score:   verdict.Score,
+			Reasons: verdict.Reasons(),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not create user", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not create user", err)
+		return
+	}
+
 	respondWithJSON(w, 201, DatabaseUserToAPIUser(dbUser))
 }
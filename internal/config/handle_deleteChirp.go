@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/stream"
 )
 
 // Add a new DELETE /api/chirps/{chirpID} route to your server that deletes a chirp from the database by its id.
@@ -11,6 +12,8 @@ import (
 // If they are not, return a 403 status code.
 // If the chirp is deleted successfully, return a 204 status code.
 // If the chirp is not found, return a 404 status code.
+//
+// Chirps are soft-deleted: the author can restore them with POST /api/chirps/{chirpID}/restore until they are purged after the retention window.
 func (cfg *ApiConfig) HandleDeleteChirp(w http.ResponseWriter, r *http.Request) {
 	// authenticate requesting user
 	requestingUserID, ok := cfg.authenticateUser(w, r)
@@ -32,6 +35,13 @@ func (cfg *ApiConfig) HandleDeleteChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	if requestingUserID != chirpToDelete.UserID {
+		cfg.recordAuditEvent(r, auditEvent{
+			eventType: auditChirpDelete,
+			failed:    true,
+			actorID:   requestingUserID,
+			targetID:  chirpUUID,
+			details:   map[string]string{"reason": "not the author"},
+		})
 		respondWithError(w, http.StatusForbidden, "", nil)
 		return
 	}
@@ -42,6 +52,18 @@ func (cfg *ApiConfig) HandleDeleteChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	cfg.recordAuditEvent(r, auditEvent{eventType: auditChirpDelete, actorID: requestingUserID, targetID: chirpUUID})
+
+	// notify real-time subscribers
+	type ChirpDeletedEvent struct {
+		Id     uuid.UUID `json:"id"`
+		UserId uuid.UUID `json:"user_id"`
+	}
+	cfg.publishChirpEvent(r.Context(), stream.ChirpDeleted, chirpToDelete, ChirpDeletedEvent{
+		Id:     chirpToDelete.ID,
+		UserId: chirpToDelete.UserID,
+	})
+
 	// Return 204 No Content on success
 	w.WriteHeader(http.StatusNoContent)
 }
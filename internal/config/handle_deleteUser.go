@@ -0,0 +1,83 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rickNoise/chirpy/internal/auth"
+	"github.com/rickNoise/chirpy/internal/stream"
+)
+
+// how long a deleted account can still be restored (by logging in) before it is purged
+const accountDeletionGracePeriod = 30 * 24 * time.Hour
+
+// DELETE /api/users/me deletes the authenticated user's account. The user's password is required to confirm:
+//
+//	{"password": "04234"}
+//
+// The account is soft-deleted: it is hidden (along with its chirps) and all its refresh tokens are revoked straight away,
+// but nothing is removed until the 30 day grace period has passed, and logging in again before then cancels the deletion.
+// On success it responds with a 202 status code and the time the account will be purged.
+func (cfg *ApiConfig) HandleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Password string `json:"password"`
+	}
+
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "could not decode request body", err)
+		return
+	}
+
+	dbUser, err := cfg.DbQueries.GetUserById(r.Context(), userID)
+	if err != nil || dbUser.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+	if err := auth.CheckPasswordHash(params.Password, dbUser.HashedPassword); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "incorrect password", err)
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not delete user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	dbDeletedUser, err := qtx.SoftDeleteUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not delete user", err)
+		return
+	}
+	if err := qtx.RevokeAllRefreshTokensForUser(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not delete user", fmt.Errorf("error revoking refresh tokens: %w", err))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not delete user", err)
+		return
+	}
+
+	cfg.publishUserEvent(r.Context(), stream.UserUpdated, userID)
+
+	type DeleteUserResponse struct {
+		DeletedAt  time.Time `json:"deleted_at"`
+		PurgeAfter time.Time `json:"purge_after"`
+	}
+	respondWithJSON(w, http.StatusAccepted, DeleteUserResponse{
+		DeletedAt:  dbDeletedUser.DeletedAt.Time,
+		PurgeAfter: dbDeletedUser.DeletedAt.Time.Add(accountDeletionGracePeriod),
+	})
+}
@@ -0,0 +1,285 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+)
+
+// the maximum length of a direct message, in characters
+const maxDirectMessageLength = 1000
+
+// A page of conversations. NextCursor is set when there may be more conversations; pass it as ?cursor= to get the next page.
+type ConversationPage struct {
+	Conversations []Conversation `json:"conversations"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+// A page of messages. NextCursor is set when there may be more messages; pass it as ?cursor= to get the next page.
+type DirectMessagePage struct {
+	Messages   []DirectMessage `json:"messages"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// conversationParticipants orders two user ids the way conversations store them, matching how Postgres sorts UUIDs.
+func conversationParticipants(userID, otherUserID uuid.UUID) (userAID, userBID uuid.UUID) {
+	if bytes.Compare(userID[:], otherUserID[:]) < 0 {
+		return userID, otherUserID
+	}
+	return otherUserID, userID
+}
+
+// conversationOtherUser returns the participant of a conversation who isn't userID.
+func conversationOtherUser(c database.Conversation, userID uuid.UUID) uuid.UUID {
+	if c.UserAID == userID {
+		return c.UserBID
+	}
+	return c.UserAID
+}
+
+// POST /api/conversations starts a direct message conversation between the authenticated user and another user, given as {"user_id": "..."}.
+// Each pair of users has a single conversation: it responds with a 201 status code and the new conversation, or a 200 status code and the existing one.
+// Users who have blocked each other can't start a conversation.
+func (cfg *ApiConfig) HandleStartConversation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	type parameters struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "error decoding req json body", err)
+		return
+	}
+	if params.UserID == userID {
+		respondWithError(w, http.StatusBadRequest, "cannot start a conversation with yourself", nil)
+		return
+	}
+
+	if _, err := cfg.DbQueries.GetActiveUserById(r.Context(), params.UserID); err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+	if !cfg.checkNotBlocked(w, r, userID, params.UserID) {
+		return // helper already wrote the error response
+	}
+
+	userAID, userBID := conversationParticipants(userID, params.UserID)
+	status := http.StatusOK
+	dbConversation, err := cfg.DbQueries.GetConversationBetween(r.Context(), database.GetConversationBetweenParams{
+		UserAID: userAID,
+		UserBID: userBID,
+	})
+	if err != nil {
+		status = http.StatusCreated
+		dbConversation, err = cfg.DbQueries.CreateConversation(r.Context(), database.CreateConversationParams{
+			UserAID: userAID,
+			UserBID: userBID,
+		})
+		// a concurrent request may have started the conversation in the meantime
+		if checkForUniqueConstraintViolationPostgresql(err) {
+			status = http.StatusOK
+			dbConversation, err = cfg.DbQueries.GetConversationBetween(r.Context(), database.GetConversationBetweenParams{
+				UserAID: userAID,
+				UserBID: userBID,
+			})
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not start conversation", err)
+			return
+		}
+	}
+
+	jsonConversations, err := cfg.conversationsToAPI(r.Context(), userID, []database.Conversation{dbConversation})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get conversation", err)
+		return
+	}
+
+	respondWithJSON(w, status, jsonConversations[0])
+}
+
+// GET /api/conversations lists the authenticated user's conversations, most recently active first, each with its number of unread messages.
+// It returns a page of up to ?limit= conversations (default 20, at most 100) along with a next_cursor for the following page.
+func (cfg *ApiConfig) HandleGetConversations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	limit, cursor, ok := parsePageRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbConversations, err := cfg.DbQueries.GetConversationsPage(r.Context(), database.GetConversationsPageParams{
+		UserID:           userID,
+		BeforeUpdatedAt:  cursor.time,
+		BeforeID:         cursor.id,
+		MaxConversations: limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get conversations", err)
+		return
+	}
+	jsonConversations, err := cfg.conversationsToAPI(r.Context(), userID, dbConversations)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get conversations", err)
+		return
+	}
+
+	page := ConversationPage{Conversations: jsonConversations}
+	if len(dbConversations) == int(limit) {
+		last := dbConversations[len(dbConversations)-1]
+		page.NextCursor = pageCursor{time: last.UpdatedAt, id: last.ID}.String()
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// POST /api/conversations/{conversationID}/messages sends a message, given as {"body": "..."}, in one of the authenticated user's conversations.
+// Messages can't be sent while either participant has blocked the other, or once the other participant's account is pending deletion. It responds with a 201 status code and the message.
+func (cfg *ApiConfig) HandleSendDirectMessage(w http.ResponseWriter, r *http.Request) {
+	userID, dbConversation, ok := cfg.loadConversation(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	type parameters struct {
+		Body string `json:"body"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "error decoding req json body", err)
+		return
+	}
+	if strings.TrimSpace(params.Body) == "" {
+		respondWithError(w, http.StatusBadRequest, "message cannot have an empty body", nil)
+		return
+	}
+	if utf8.RuneCountInString(params.Body) > maxDirectMessageLength {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("message can be at most %d characters", maxDirectMessageLength), nil)
+		return
+	}
+
+	otherUserID := conversationOtherUser(dbConversation, userID)
+	if _, err := cfg.DbQueries.GetActiveUserById(r.Context(), otherUserID); err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+	if !cfg.checkNotBlocked(w, r, userID, otherUserID) {
+		return // helper already wrote the error response
+	}
+
+	dbMessage, err := cfg.DbQueries.CreateDirectMessage(r.Context(), database.CreateDirectMessageParams{
+		ConversationID: dbConversation.ID,
+		SenderID:       userID,
+		Body:           params.Body,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not send message", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, DatabaseDirectMessageToAPIDirectMessage(dbMessage))
+}
+
+// GET /api/conversations/{conversationID}/messages lists the messages in one of the authenticated user's conversations, newest first.
+// It returns a page of up to ?limit= messages (default 20, at most 100) along with a next_cursor for the following page.
+// Reading messages doesn't mark them as read; clients do that explicitly with POST /api/conversations/{conversationID}/read.
+func (cfg *ApiConfig) HandleGetDirectMessages(w http.ResponseWriter, r *http.Request) {
+	_, dbConversation, ok := cfg.loadConversation(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	limit, cursor, ok := parsePageRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbMessages, err := cfg.DbQueries.GetDirectMessagesPage(r.Context(), database.GetDirectMessagesPageParams{
+		ConversationID:  dbConversation.ID,
+		BeforeCreatedAt: cursor.time,
+		BeforeID:        cursor.id,
+		MaxMessages:     limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get messages", err)
+		return
+	}
+
+	page := DirectMessagePage{Messages: make([]DirectMessage, 0, len(dbMessages))}
+	for _, m := range dbMessages {
+		page.Messages = append(page.Messages, DatabaseDirectMessageToAPIDirectMessage(m))
+	}
+	if len(dbMessages) == int(limit) {
+		last := dbMessages[len(dbMessages)-1]
+		page.NextCursor = pageCursor{time: last.CreatedAt, id: last.ID}.String()
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// POST /api/conversations/{conversationID}/read marks every message the authenticated user has received in a conversation as read,
+// which sets their read_at for the sender to see. It responds with a 204 status code.
+func (cfg *ApiConfig) HandleMarkConversationRead(w http.ResponseWriter, r *http.Request) {
+	userID, dbConversation, ok := cfg.loadConversation(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	_, err := cfg.DbQueries.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ConversationID: dbConversation.ID,
+		UserID:         userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not mark conversation as read", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadConversation authenticates the user and loads the {conversationID} conversation,
+// writing an error response if it doesn't exist or the user isn't one of its participants.
+func (cfg *ApiConfig) loadConversation(w http.ResponseWriter, r *http.Request) (uuid.UUID, database.Conversation, bool) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return uuid.Nil, database.Conversation{}, false
+	}
+
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid conversation id", err)
+		return uuid.Nil, database.Conversation{}, false
+	}
+
+	dbConversation, err := cfg.DbQueries.GetConversation(r.Context(), conversationID)
+	if err != nil || (dbConversation.UserAID != userID && dbConversation.UserBID != userID) {
+		respondWithError(w, http.StatusNotFound, "conversation not found", err)
+		return uuid.Nil, database.Conversation{}, false
+	}
+
+	return userID, dbConversation, true
+}
+
+// checkNotBlocked writes an error response if either user has blocked the other.
+func (cfg *ApiConfig) checkNotBlocked(w http.ResponseWriter, r *http.Request, userID, otherUserID uuid.UUID) bool {
+	blocked, err := cfg.DbQueries.GetBlockRelatedUserIDs(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not load blocks", err)
+		return false
+	}
+	if slices.Contains(blocked, otherUserID) {
+		respondWithError(w, http.StatusForbidden, "cannot message this user", nil)
+		return false
+	}
+	return true
+}
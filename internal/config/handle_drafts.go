@@ -0,0 +1,253 @@
+package config
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/chirptext"
+	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/stream"
+)
+
+// maximum length of a draft body, counted like a chirp (see chirptext.Length); drafts may be longer than a chirp while they are being worked on
+const maxDraftLength = 10 * maxChirpLength
+
+// draftParameters is the request body of POST /api/drafts and PUT /api/drafts/{draftID}.
+type draftParameters struct {
+	Body       string `json:"body"`
+	Visibility string `json:"visibility"` // optional; defaults to public
+}
+
+// decodeDraftParameters decodes and checks a draft request body, writing an error response if it is invalid.
+// Drafts are only checked against the chirp rules when they are published.
+func decodeDraftParameters(w http.ResponseWriter, r *http.Request) (draftParameters, bool) {
+	params := draftParameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "error decoding req json body", err)
+		return params, false
+	}
+	params.Body = chirptext.Normalize(params.Body)
+	if chirptext.Length(params.Body) > maxDraftLength {
+		respondWithError(w, http.StatusBadRequest, "draft is too long", nil)
+		return params, false
+	}
+	if params.Visibility == "" {
+		params.Visibility = chirpVisibilityPublic
+	}
+	if !slices.Contains(chirpVisibilities, params.Visibility) {
+		respondWithError(w, http.StatusBadRequest, "visibility must be one of public, followers, mentioned or unlisted", nil)
+		return params, false
+	}
+	return params, true
+}
+
+// POST /api/drafts saves a new draft for the authenticated user. It responds with a 201 status code and the draft.
+func (cfg *ApiConfig) HandleCreateDraft(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	params, ok := decodeDraftParameters(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbDraft, err := cfg.DbQueries.CreateDraft(r.Context(), database.CreateDraftParams{
+		UserID:     userID,
+		Body:       params.Body,
+		Visibility: params.Visibility,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not save draft", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, DatabaseDraftToAPIDraft(dbDraft))
+}
+
+// GET /api/drafts lists the authenticated user's drafts, most recently edited first.
+func (cfg *ApiConfig) HandleGetDrafts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbDrafts, err := cfg.DbQueries.GetDraftsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get drafts", err)
+		return
+	}
+
+	jsonDrafts := make([]Draft, 0, len(dbDrafts))
+	for _, d := range dbDrafts {
+		jsonDrafts = append(jsonDrafts, DatabaseDraftToAPIDraft(d))
+	}
+	respondWithJSON(w, http.StatusOK, jsonDrafts)
+}
+
+// GET /api/drafts/{draftID} returns one of the authenticated user's drafts, or 404 if there is no such draft.
+func (cfg *ApiConfig) HandleGetDraft(w http.ResponseWriter, r *http.Request) {
+	userID, draftID, ok := cfg.parseDraftRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbDraft, err := cfg.DbQueries.GetDraft(r.Context(), database.GetDraftParams{ID: draftID, UserID: userID})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "draft not found", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, DatabaseDraftToAPIDraft(dbDraft))
+}
+
+// PUT /api/drafts/{draftID} replaces the body and visibility of one of the authenticated user's drafts.
+// It responds with a 200 status code and the updated draft, or 404 if there is no such draft.
+func (cfg *ApiConfig) HandleUpdateDraft(w http.ResponseWriter, r *http.Request) {
+	userID, draftID, ok := cfg.parseDraftRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	params, ok := decodeDraftParameters(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbDraft, err := cfg.DbQueries.UpdateDraft(r.Context(), database.UpdateDraftParams{
+		Body:       params.Body,
+		Visibility: params.Visibility,
+		ID:         draftID,
+		UserID:     userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "draft not found", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not update draft", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, DatabaseDraftToAPIDraft(dbDraft))
+}
+
+// DELETE /api/drafts/{draftID} discards one of the authenticated user's drafts. It responds with a 204 status code, or 404 if there is no such draft.
+func (cfg *ApiConfig) HandleDeleteDraft(w http.ResponseWriter, r *http.Request) {
+	userID, draftID, ok := cfg.parseDraftRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	_, err := cfg.DbQueries.DeleteDraft(r.Context(), database.DeleteDraftParams{ID: draftID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "draft not found", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not delete draft", err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/drafts/{draftID}/publish publishes one of the authenticated user's drafts as a chirp.
+// The draft body is checked, censored and scored by the spam pipeline like POST /api/chirps. The draft is deleted and the chirp created in the same transaction,
+// so a draft is published at most once even if the request is repeated, and an invalid draft is left untouched.
+// On success it responds with a 201 status code and the new chirp.
+func (cfg *ApiConfig) HandlePublishDraft(w http.ResponseWriter, r *http.Request) {
+	userID, draftID, ok := cfg.parseDraftRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not publish draft", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	// taking the draft locks it, so a concurrent publish of the same draft finds nothing
+	dbDraft, err := qtx.DeleteDraft(r.Context(), database.DeleteDraftParams{ID: draftID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "draft not found", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not publish draft", err)
+		}
+		return
+	}
+
+	if err := validateChirpBody(dbDraft.Body); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	_, censoredBody := censorChirp(dbDraft.Body)
+
+	dbUser, err := qtx.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+	verdict, ok := cfg.checkChirpSpam(w, r, dbUser, censoredBody)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbChirp, err := qtx.CreateChirp(r.Context(), database.CreateChirpParams{
+		Body:       censoredBody,
+		UserID:     userID,
+		Visibility: dbDraft.Visibility,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not publish draft", err)
+		return
+	}
+	if err := storeChirpEntities(r.Context(), qtx, dbChirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not publish draft", err)
+		return
+	}
+	if err := flagChirp(r.Context(), qtx, dbChirp.ID, verdict); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not publish draft", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not publish draft", err)
+		return
+	}
+	cfg.enqueueChirpLinkPreview(dbChirp)
+
+	jsonChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), userID, []database.Chirp{dbChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not load chirp entities", err)
+		return
+	}
+
+	// notify real-time subscribers
+	cfg.publishChirpEvent(r.Context(), stream.ChirpCreated, dbChirp, jsonChirps[0])
+
+	respondWithJSON(w, http.StatusCreated, jsonChirps[0])
+}
+
+// parseDraftRequest authenticates the user and parses the {draftID} they are acting on.
+func (cfg *ApiConfig) parseDraftRequest(w http.ResponseWriter, r *http.Request) (userID, draftID uuid.UUID, ok bool) {
+	userID, ok = cfg.authenticateUser(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid draft id", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, draftID, true
+}
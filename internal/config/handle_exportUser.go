@@ -0,0 +1,381 @@
+package config
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/logging"
+)
+
+// A user's data export. Refresh tokens are listed as sessions, without the token itself.
+type UserExport struct {
+	ExportedAt             time.Time                  `json:"exported_at"`
+	Profile                User                       `json:"profile"`
+	Chirps                 []Chirp                    `json:"chirps"`
+	Drafts                 []Draft                    `json:"drafts"`
+	Bookmarks              []ExportedBookmark         `json:"bookmarks"`
+	Collections            []ExportedCollection       `json:"collections"`
+	Lists                  []ExportedUserList         `json:"lists"`
+	ListSubscriptions      []ExportedListSubscription `json:"list_subscriptions"`
+	Conversations          []ExportedConversation     `json:"conversations"`
+	Following              []ExportedRelationship     `json:"following"`
+	Followers              []ExportedRelationship     `json:"followers"`
+	SentFollowRequests     []ExportedRelationship     `json:"sent_follow_requests"`
+	ReceivedFollowRequests []ExportedRelationship     `json:"received_follow_requests"`
+	Blocks                 []ExportedRelationship     `json:"blocks"`
+	Mutes                  []ExportedRelationship     `json:"mutes"`
+	PollVotes              []ExportedPollVote         `json:"poll_votes"`
+	Media                  []Media                    `json:"media"`
+	Sessions               []Session                  `json:"sessions"`
+}
+
+// Bookmarks and collections are exported as chirp ids, since the chirps may belong to other users.
+type ExportedBookmark struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ExportedCollection struct {
+	Collection             // anonymous embedding
+	ChirpIDs   []uuid.UUID `json:"chirp_ids"`
+}
+
+// Lists the user owns, along with their members; subscriptions to other users' lists are exported separately as list ids.
+type ExportedUserList struct {
+	UserList              // anonymous embedding
+	MemberIDs []uuid.UUID `json:"member_ids"`
+}
+
+// Conversations are exported with all of their messages, both sent and received.
+type ExportedConversation struct {
+	Conversation                 // anonymous embedding
+	Messages     []DirectMessage `json:"messages"`
+}
+
+type ExportedListSubscription struct {
+	ListID    uuid.UUID `json:"list_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// A relationship with another user, such as a follow or a block, exported as the other user's id.
+// Follows are split by direction and status; pending ones are follow requests to or from private accounts.
+type ExportedRelationship struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// A vote in the poll attached to a chirp; position is the index of the chosen option.
+type ExportedPollVote struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	Position  int32     `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Session struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// GET /api/users/me/export downloads a copy of everything stored about the authenticated user: their profile, chirps, drafts, bookmarks, collections, lists,
+// direct messages, follows and follow requests, blocks and mutes, poll votes, uploaded media and sessions.
+// Chirps include scheduled chirps and deleted chirps that can still be restored, which have their deleted_at set.
+// By default the export is a ZIP archive holding export.json and the processed media files under media/; with ?format=json only the JSON document is returned.
+func (cfg *ApiConfig) HandleExportUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "zip"
+	}
+	if format != "zip" && format != "json" {
+		respondWithError(w, http.StatusBadRequest, "format must be zip or json", nil)
+		return
+	}
+
+	dbUser, err := cfg.DbQueries.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+
+	dbChirps, err := cfg.DbQueries.GetAllChirpsByAuthorUserId(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export chirps", err)
+		return
+	}
+	dbScheduledChirps, err := cfg.DbQueries.GetScheduledChirpsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export chirps", err)
+		return
+	}
+	dbDeletedChirps, err := cfg.DbQueries.GetDeletedChirpsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export chirps", err)
+		return
+	}
+	dbChirps = append(dbChirps, dbScheduledChirps...)
+	dbChirps = append(dbChirps, dbDeletedChirps...)
+	jsonChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), userID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export chirps", err)
+		return
+	}
+
+	dbDrafts, err := cfg.DbQueries.GetDraftsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export drafts", err)
+		return
+	}
+	jsonDrafts := make([]Draft, 0, len(dbDrafts))
+	for _, d := range dbDrafts {
+		jsonDrafts = append(jsonDrafts, DatabaseDraftToAPIDraft(d))
+	}
+
+	dbBookmarks, err := cfg.DbQueries.GetBookmarksForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export bookmarks", err)
+		return
+	}
+	bookmarks := make([]ExportedBookmark, 0, len(dbBookmarks))
+	for _, b := range dbBookmarks {
+		bookmarks = append(bookmarks, ExportedBookmark{ChirpID: b.ChirpID, CreatedAt: b.CreatedAt})
+	}
+
+	dbCollections, err := cfg.DbQueries.GetCollectionsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export collections", err)
+		return
+	}
+	collections := make([]ExportedCollection, 0, len(dbCollections))
+	for _, c := range dbCollections {
+		chirpIDs, err := cfg.DbQueries.GetCollectionChirpIDs(r.Context(), c.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not export collections", err)
+			return
+		}
+		if chirpIDs == nil {
+			chirpIDs = []uuid.UUID{}
+		}
+		collections = append(collections, ExportedCollection{Collection: DatabaseCollectionToAPICollection(c), ChirpIDs: chirpIDs})
+	}
+
+	dbLists, err := cfg.DbQueries.GetUserListsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export lists", err)
+		return
+	}
+	jsonLists, err := cfg.userListsToAPI(r.Context(), dbLists)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export lists", err)
+		return
+	}
+	lists := make([]ExportedUserList, 0, len(jsonLists))
+	for _, l := range jsonLists {
+		dbMembers, err := cfg.DbQueries.GetUserListMembers(r.Context(), l.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not export lists", err)
+			return
+		}
+		memberIDs := make([]uuid.UUID, 0, len(dbMembers))
+		for _, m := range dbMembers {
+			memberIDs = append(memberIDs, m.UserID)
+		}
+		lists = append(lists, ExportedUserList{UserList: l, MemberIDs: memberIDs})
+	}
+
+	dbListSubs, err := cfg.DbQueries.GetUserListSubscriptionsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export list subscriptions", err)
+		return
+	}
+	listSubscriptions := make([]ExportedListSubscription, 0, len(dbListSubs))
+	for _, s := range dbListSubs {
+		listSubscriptions = append(listSubscriptions, ExportedListSubscription{ListID: s.ListID, CreatedAt: s.CreatedAt})
+	}
+
+	dbConversations, err := cfg.DbQueries.GetConversationsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export conversations", err)
+		return
+	}
+	jsonConversations, err := cfg.conversationsToAPI(r.Context(), userID, dbConversations)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export conversations", err)
+		return
+	}
+	conversations := make([]ExportedConversation, 0, len(jsonConversations))
+	for _, c := range jsonConversations {
+		dbMessages, err := cfg.DbQueries.GetDirectMessagesForConversation(r.Context(), c.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not export conversations", err)
+			return
+		}
+		messages := make([]DirectMessage, 0, len(dbMessages))
+		for _, m := range dbMessages {
+			messages = append(messages, DatabaseDirectMessageToAPIDirectMessage(m))
+		}
+		conversations = append(conversations, ExportedConversation{Conversation: c, Messages: messages})
+	}
+
+	dbFollows, err := cfg.DbQueries.GetFollowsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export follows", err)
+		return
+	}
+	following := []ExportedRelationship{}
+	followers := []ExportedRelationship{}
+	sentFollowRequests := []ExportedRelationship{}
+	receivedFollowRequests := []ExportedRelationship{}
+	for _, f := range dbFollows {
+		switch {
+		case f.FollowerID == userID && f.Status == followStatusAccepted:
+			following = append(following, ExportedRelationship{UserID: f.FolloweeID, CreatedAt: f.CreatedAt})
+		case f.FollowerID == userID:
+			sentFollowRequests = append(sentFollowRequests, ExportedRelationship{UserID: f.FolloweeID, CreatedAt: f.CreatedAt})
+		case f.Status == followStatusAccepted:
+			followers = append(followers, ExportedRelationship{UserID: f.FollowerID, CreatedAt: f.CreatedAt})
+		default:
+			receivedFollowRequests = append(receivedFollowRequests, ExportedRelationship{UserID: f.FollowerID, CreatedAt: f.CreatedAt})
+		}
+	}
+
+	dbBlocks, err := cfg.DbQueries.GetBlocksByUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export blocks", err)
+		return
+	}
+	blocks := make([]ExportedRelationship, 0, len(dbBlocks))
+	for _, b := range dbBlocks {
+		blocks = append(blocks, ExportedRelationship{UserID: b.BlockedID, CreatedAt: b.CreatedAt})
+	}
+
+	dbMutes, err := cfg.DbQueries.GetMutesByUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export mutes", err)
+		return
+	}
+	mutes := make([]ExportedRelationship, 0, len(dbMutes))
+	for _, m := range dbMutes {
+		mutes = append(mutes, ExportedRelationship{UserID: m.MutedID, CreatedAt: m.CreatedAt})
+	}
+
+	dbPollVotes, err := cfg.DbQueries.GetPollVotesForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export poll votes", err)
+		return
+	}
+	pollVotes := make([]ExportedPollVote, 0, len(dbPollVotes))
+	for _, v := range dbPollVotes {
+		pollVotes = append(pollVotes, ExportedPollVote{ChirpID: v.ChirpID, Position: v.Position, CreatedAt: v.CreatedAt})
+	}
+
+	dbMedia, err := cfg.DbQueries.GetMediaForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export media", err)
+		return
+	}
+	jsonMedia, err := cfg.databaseMediaToAPIMedia(r.Context(), dbMedia)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export media", err)
+		return
+	}
+
+	dbTokens, err := cfg.DbQueries.GetRefreshTokensForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not export sessions", err)
+		return
+	}
+	sessions := make([]Session, 0, len(dbTokens))
+	for _, t := range dbTokens {
+		session := Session{CreatedAt: t.CreatedAt, ExpiresAt: t.ExpiresAt}
+		if t.RevokedAt.Valid {
+			session.RevokedAt = &t.RevokedAt.Time
+		}
+		sessions = append(sessions, session)
+	}
+
+	export := UserExport{
+		ExportedAt:             time.Now().UTC(),
+		Profile:                DatabaseUserToAPIUser(dbUser),
+		Chirps:                 jsonChirps,
+		Drafts:                 jsonDrafts,
+		Bookmarks:              bookmarks,
+		Collections:            collections,
+		Lists:                  lists,
+		ListSubscriptions:      listSubscriptions,
+		Conversations:          conversations,
+		Following:              following,
+		Followers:              followers,
+		SentFollowRequests:     sentFollowRequests,
+		ReceivedFollowRequests: receivedFollowRequests,
+		Blocks:                 blocks,
+		Mutes:                  mutes,
+		PollVotes:              pollVotes,
+		Media:                  jsonMedia,
+		Sessions:               sessions,
+	}
+	if export.Chirps == nil {
+		export.Chirps = []Chirp{}
+	}
+
+	filename := "chirpy-export-" + export.ExportedAt.Format("20060102")
+	if format == "json" {
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		respondWithJSON(w, http.StatusOK, export)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+	w.WriteHeader(http.StatusOK)
+
+	// the status has been sent, so from here on errors can only be logged
+	if err := cfg.writeUserExportZip(r, w, export, dbMedia); err != nil {
+		logging.FromContext(r.Context()).Warn("could not write user export", "error", err)
+	}
+}
+
+func (cfg *ApiConfig) writeUserExportZip(r *http.Request, w io.Writer, export UserExport, dbMedia []database.Medium) error {
+	archive := zip.NewWriter(w)
+
+	f, err := archive.Create("export.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return err
+	}
+
+	// only processed media is included; unprocessed uploads may still carry location metadata
+	for _, m := range dbMedia {
+		if m.ProcessingStatus != mediaStatusReady {
+			continue
+		}
+		blob, err := cfg.Blobs.Get(r.Context(), m.StorageKey)
+		if err != nil {
+			return fmt.Errorf("could not read media %s: %w", m.ID, err)
+		}
+		f, err := archive.Create("media/" + m.StorageKey)
+		if err == nil {
+			_, err = io.Copy(f, blob)
+		}
+		blob.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
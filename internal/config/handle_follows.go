@@ -0,0 +1,170 @@
+package config
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/rickNoise/chirpy/internal/database"
+)
+
+// follow statuses, as stored in follows.status
+const followStatusPending = "pending"
+const followStatusAccepted = "accepted"
+
+// POST /api/users/{userID}/follow follows a user for the authenticated user.
+// Following a public account takes effect immediately; following a private account sends a follow request the account has to approve.
+// Following an already followed (or requested) user is a no-op, and users who have blocked each other cannot follow each other.
+// On success it responds with a 200 status code and the follow's status, either "accepted" or "pending".
+func (cfg *ApiConfig) HandleFollowUser(w http.ResponseWriter, r *http.Request) {
+	followerID, followeeID, ok := cfg.parseUserRelationshipRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	followee, err := cfg.DbQueries.GetActiveUserById(r.Context(), followeeID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+
+	blocked, err := cfg.DbQueries.GetBlockRelatedUserIDs(r.Context(), followerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not follow user", err)
+		return
+	}
+	if slices.Contains(blocked, followeeID) {
+		respondWithError(w, http.StatusForbidden, "cannot follow this user", nil)
+		return
+	}
+
+	status := followStatusAccepted
+	if followee.IsPrivate {
+		status = followStatusPending
+	}
+	dbFollow, err := cfg.DbQueries.CreateFollow(r.Context(), database.CreateFollowParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+		Status:     status,
+	})
+	if err != nil {
+		if checkForForeignKeyConstraintViolationPostgresql(err) {
+			respondWithError(w, http.StatusNotFound, "user not found", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not follow user", err)
+		}
+		return
+	}
+	if dbFollow.Status == followStatusAccepted {
+		cfg.publishRelationshipsChanged(r.Context(), followerID)
+	}
+
+	type FollowResponse struct {
+		Status    string    `json:"status"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	respondWithJSON(w, http.StatusOK, FollowResponse{
+		Status:    dbFollow.Status,
+		CreatedAt: dbFollow.CreatedAt,
+	})
+}
+
+// DELETE /api/users/{userID}/follow unfollows a user, or cancels a pending follow request.
+// It responds with a 204 status code, or 404 if the user wasn't followed.
+func (cfg *ApiConfig) HandleUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	followerID, followeeID, ok := cfg.parseUserRelationshipRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	rows, err := cfg.DbQueries.DeleteFollow(r.Context(), database.DeleteFollowParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not unfollow user", err)
+		return
+	}
+	if rows == 0 {
+		respondWithError(w, http.StatusNotFound, "user is not followed", nil)
+		return
+	}
+	cfg.publishRelationshipsChanged(r.Context(), followerID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/users/me/follow-requests lists the pending follow requests to the authenticated user, most recent first.
+func (cfg *ApiConfig) HandleGetFollowRequests(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbRequests, err := cfg.DbQueries.GetFollowRequests(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get follow requests", err)
+		return
+	}
+
+	relationships := make([]userRelationship, 0, len(dbRequests))
+	for _, f := range dbRequests {
+		relationships = append(relationships, userRelationship{userID: f.FollowerID, createdAt: f.CreatedAt})
+	}
+	jsonRelationships, err := cfg.userRelationshipsToAPI(r.Context(), relationships)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get follow requests", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jsonRelationships)
+}
+
+// POST /api/users/me/follow-requests/{userID}/approve approves a pending follow request from the provided user.
+// It responds with a 204 status code, or 404 if there is no such request.
+func (cfg *ApiConfig) HandleApproveFollowRequest(w http.ResponseWriter, r *http.Request) {
+	followeeID, followerID, ok := cfg.parseUserRelationshipRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	rows, err := cfg.DbQueries.AcceptFollowRequest(r.Context(), database.AcceptFollowRequestParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not approve follow request", err)
+		return
+	}
+	if rows == 0 {
+		respondWithError(w, http.StatusNotFound, "follow request not found", nil)
+		return
+	}
+	cfg.publishRelationshipsChanged(r.Context(), followerID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/users/me/follow-requests/{userID}/deny denies a pending follow request from the provided user; they can request again later.
+// It responds with a 204 status code, or 404 if there is no such request.
+func (cfg *ApiConfig) HandleDenyFollowRequest(w http.ResponseWriter, r *http.Request) {
+	followeeID, followerID, ok := cfg.parseUserRelationshipRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	rows, err := cfg.DbQueries.DenyFollowRequest(r.Context(), database.DenyFollowRequestParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not deny follow request", err)
+		return
+	}
+	if rows == 0 {
+		respondWithError(w, http.StatusNotFound, "follow request not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -19,7 +19,15 @@ import (
 // GET http://localhost:8080/api/chirps?author_id=1
 //
 // Continue sorting the chirps by created_at in ascending order.
+//
+// Authenticated viewers don't see chirps from users they have blocked or been blocked by, and the unfiltered timeline also leaves out users they have muted.
+// Chirps by private accounts are only included for the author and their approved followers.
 func (cfg *ApiConfig) HandleGetAllChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := cfg.authenticateOptionalUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
 	// check for optional author_id query parameter.
 	rawAuthorId := r.URL.Query().Get("author_id")
 
@@ -60,16 +68,23 @@ func (cfg *ApiConfig) HandleGetAllChirps(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	// hide chirps the viewer shouldn't see; mutes only apply to the timeline, not to a specifically requested author
+	dbChirps, dbErr = cfg.filterChirpsForViewer(r.Context(), viewerID, dbChirps, rawAuthorId == "")
+	if dbErr != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirps", dbErr)
+		return
+	}
+
 	// sort dbChirps based on sort variable; if "asc" do nothing, as db query does this by default
 	if sortDirection == "desc" {
 		sort.Slice(dbChirps, func(i, j int) bool { return dbChirps[i].CreatedAt.After(dbChirps[j].CreatedAt) })
 	}
 
-	// assemble json response by looping over the db chirps
-	// chirps should already be sorted by the db queries ASC by created_at
-	var jsonChirps []Chirp
-	for _, dbChirp := range dbChirps {
-		jsonChirps = append(jsonChirps, DatabaseChirpToAPIChirp(dbChirp))
+	// assemble json response from the db chirps, keeping their order
+	jsonChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), viewerID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirp entities", err)
+		return
 	}
 
 	respondWithJSON(w, http.StatusOK, jsonChirps)
@@ -2,14 +2,19 @@ package config
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 
 	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
 )
 
+// GET /api/chirps/{chirpID} returns a single chirp.
+// Chirps the viewer isn't allowed to see (e.g. from a user who has blocked them, a private account they don't follow, or a followers-only or mentioned-only chirp outside their audience) respond with a 404 status code, as if they didn't exist.
 func (cfg *ApiConfig) HandleGetChirp(w http.ResponseWriter, r *http.Request) {
-	fmt.Printf("Handling Get Chirp request for chirpID: %s\n", r.PathValue("chirpID"))
+	viewerID, ok := cfg.authenticateOptionalUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
 
 	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
 	if err != nil {
@@ -23,5 +28,21 @@ func (cfg *ApiConfig) HandleGetChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, DatabaseChirpToAPIChirp(dbChirp))
+	visibleChirps, err := cfg.filterChirpsForViewer(r.Context(), viewerID, []database.Chirp{dbChirp}, false)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirp", err)
+		return
+	}
+	if len(visibleChirps) == 0 {
+		respondWithError(w, http.StatusNotFound, "no chirp found with that ID", nil)
+		return
+	}
+
+	jsonChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), viewerID, []database.Chirp{dbChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirp entities", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jsonChirps[0])
 }
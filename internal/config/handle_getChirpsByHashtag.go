@@ -0,0 +1,43 @@
+package config
+
+import (
+	"net/http"
+
+	"github.com/rickNoise/chirpy/internal/chirptext"
+)
+
+// GET /api/hashtags/{tag}/chirps returns all chirps tagged with the provided hashtag, sorted by created_at in ascending order.
+// Matching is case-insensitive, and the tag may be given with or without a leading (url-encoded) '#'.
+// Authenticated viewers don't see chirps from users they have blocked, been blocked by or muted, nor chirps by private accounts they don't follow.
+func (cfg *ApiConfig) HandleGetChirpsByHashtag(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := cfg.authenticateOptionalUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	tag := chirptext.NormalizeHashtag(r.PathValue("tag"))
+	if tag == "" {
+		respondWithError(w, http.StatusBadRequest, "invalid hashtag", nil)
+		return
+	}
+
+	dbChirps, err := cfg.DbQueries.GetChirpsByHashtag(r.Context(), tag)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirps", err)
+		return
+	}
+
+	dbChirps, err = cfg.filterChirpsForViewer(r.Context(), viewerID, dbChirps, true)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirps", err)
+		return
+	}
+
+	jsonChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), viewerID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirp entities", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jsonChirps)
+}
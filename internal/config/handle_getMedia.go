@@ -0,0 +1,175 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/media"
+)
+
+// processing status of media whose blob is safe to serve
+const mediaStatusReady = "ready"
+
+// how long clients (and, for public media, shared caches) may reuse a media response; short enough that deleted or restricted media stops being served soon after
+const mediaCacheMaxAge = "3600"
+
+// parseVisibleMediaRequest loads the {mediaID} being requested, writing a 404 if it doesn't exist or the (optionally authenticated) user may not see it.
+// Media attached to a chirp is visible to whoever can see the chirp, and the avatar of an account is visible to everyone;
+// anything else, such as an upload that hasn't been attached yet or media of a scheduled or deleted chirp, is only visible to its uploader.
+// public reports whether an anonymous user could see the media too, i.e. whether shared caches may store it.
+func (cfg *ApiConfig) parseVisibleMediaRequest(w http.ResponseWriter, r *http.Request) (dbMedia database.Medium, public bool, ok bool) {
+	userID, ok := cfg.authenticateOptionalUser(w, r)
+	if !ok {
+		return database.Medium{}, false, false
+	}
+
+	mediaID, err := uuid.Parse(r.PathValue("mediaID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid media id", err)
+		return database.Medium{}, false, false
+	}
+
+	dbMedia, err = cfg.DbQueries.GetMedia(r.Context(), mediaID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "media not found", err)
+		return database.Medium{}, false, false
+	}
+
+	visible, public, err := cfg.canSeeMedia(r.Context(), userID, dbMedia)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get media", err)
+		return database.Medium{}, false, false
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "media not found", nil)
+		return database.Medium{}, false, false
+	}
+
+	return dbMedia, public, true
+}
+
+// canSeeMedia reports whether the viewer (uuid.Nil if anonymous) may see a media item, and whether an anonymous viewer may see it too.
+func (cfg *ApiConfig) canSeeMedia(ctx context.Context, viewerID uuid.UUID, m database.Medium) (visible, public bool, err error) {
+	if !m.ChirpID.Valid {
+		_, err := cfg.DbQueries.GetUserIDByAvatar(ctx, uuid.NullUUID{UUID: m.ID, Valid: true})
+		if err == nil {
+			return true, true, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return false, false, err
+		}
+		return viewerID != uuid.Nil && viewerID == m.UserID, false, nil
+	}
+
+	chirp, err := cfg.DbQueries.GetChirp(ctx, m.ChirpID.UUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the chirp is deleted or not published yet
+		return viewerID != uuid.Nil && viewerID == m.UserID, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+
+	viewer, err := cfg.loadChirpViewer(ctx, viewerID)
+	if err != nil {
+		return false, false, err
+	}
+	audiences, err := cfg.loadChirpAudiences(ctx, []database.Chirp{chirp})
+	if err != nil {
+		return false, false, err
+	}
+	audience := audiences[chirp.ID]
+	public = chirpViewer{userID: uuid.Nil}.canSee(audience, false)
+	return viewer.canSee(audience, false), public, nil
+}
+
+// GET /api/media/{mediaID} returns the metadata for an uploaded media item.
+// Media is only visible to those who can see the chirp it is attached to (see parseVisibleMediaRequest); an access token is optional.
+func (cfg *ApiConfig) HandleGetMedia(w http.ResponseWriter, r *http.Request) {
+	dbMedia, _, ok := cfg.parseVisibleMediaRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	jsonMedia, err := cfg.databaseMediaToAPIMedia(r.Context(), []database.Medium{dbMedia})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get media", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jsonMedia[0])
+}
+
+// GET /api/media/{mediaID}/content serves the processed bytes of an uploaded media item from the blob store.
+// Until processing has finished the stored bytes are the raw upload, which may still carry EXIF/GPS metadata, so a 409 status code is returned instead.
+func (cfg *ApiConfig) HandleGetMediaContent(w http.ResponseWriter, r *http.Request) {
+	dbMedia, public, ok := cfg.parseVisibleMediaRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	if dbMedia.ProcessingStatus != mediaStatusReady {
+		respondWithError(w, http.StatusConflict, "media is "+dbMedia.ProcessingStatus, nil)
+		return
+	}
+
+	cfg.serveBlob(w, r, dbMedia.StorageKey, dbMedia.ContentType, dbMedia.SizeBytes, public)
+}
+
+// GET /api/media/{mediaID}/thumbnails/{size} serves a thumbnail of a processed media item; size is one of small, medium or large.
+func (cfg *ApiConfig) HandleGetMediaThumbnail(w http.ResponseWriter, r *http.Request) {
+	dbMedia, public, ok := cfg.parseVisibleMediaRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	thumbnail, err := cfg.DbQueries.GetMediaThumbnail(r.Context(), database.GetMediaThumbnailParams{
+		MediaID:  dbMedia.ID,
+		SizeName: r.PathValue("size"),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "thumbnail not found", err)
+		return
+	}
+
+	cfg.serveBlob(w, r, thumbnail.StorageKey, thumbnail.ContentType, -1, public)
+}
+
+// serveBlob streams a stored blob to the client; size is the content length if known, or -1, and public is whether shared caches may store it.
+func (cfg *ApiConfig) serveBlob(w http.ResponseWriter, r *http.Request, key, contentType string, size int64, public bool) {
+	blob, err := cfg.Blobs.Get(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, media.ErrNotFound) {
+			respondWithError(w, http.StatusNotFound, "media not found", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not get media", err)
+		}
+		return
+	}
+	defer blob.Close()
+
+	// the stored content type comes from our own encoder; stop browsers from second-guessing it
+	w.Header().Set("Content-Type", contentType)
+	if size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	setMediaCacheControl(w, public)
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, blob)
+}
+
+// setMediaCacheControl lets shared caches store public media, and only the requesting client store anything else.
+func setMediaCacheControl(w http.ResponseWriter, public bool) {
+	if public {
+		w.Header().Set("Cache-Control", "public, max-age="+mediaCacheMaxAge)
+	} else {
+		w.Header().Set("Cache-Control", "private, max-age="+mediaCacheMaxAge)
+	}
+}
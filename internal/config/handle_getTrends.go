@@ -0,0 +1,46 @@
+package config
+
+import (
+	"net/http"
+
+	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/trends"
+)
+
+// GET /api/trends returns the trending hashtags for a sliding window, highest velocity score first.
+// It accepts an optional window query parameter ("1h" or "24h"; defaults to "1h").
+// Trends are read from the table materialized by RunTrendsRefresher, so they may be up to one refresh interval old.
+func (cfg *ApiConfig) HandleGetTrends(w http.ResponseWriter, r *http.Request) {
+	windowName := r.URL.Query().Get("window")
+	if windowName == "" {
+		windowName = trends.Windows[0].Name
+	}
+	window, ok := trends.LookupWindow(windowName)
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "invalid window provided; expected 1h or 24h", nil)
+		return
+	}
+
+	dbTrends, err := cfg.DbQueries.GetTrendsForWindow(r.Context(), database.GetTrendsForWindowParams{
+		WindowName: window.Name,
+		MaxTrends:  maxTrendsPerWindow,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get trends", err)
+		return
+	}
+
+	type TrendsResponse struct {
+		Window string  `json:"window"`
+		Trends []Trend `json:"trends"`
+	}
+	jsonTrends := []Trend{}
+	for _, t := range dbTrends {
+		jsonTrends = append(jsonTrends, DatabaseTrendToAPITrend(t))
+	}
+
+	respondWithJSON(w, http.StatusOK, TrendsResponse{
+		Window: window.Name,
+		Trends: jsonTrends,
+	})
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// GET /api/users/{userID} returns the public profile of a user.
+// This is a public endpoint; the user's email address is never included.
+func (cfg *ApiConfig) HandleGetUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id", err)
+		return
+	}
+
+	dbUser, err := cfg.DbQueries.GetUserById(r.Context(), userID)
+	if err != nil || dbUser.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, DatabaseUserToAPIProfile(dbUser))
+}
+
+// GET /api/users/by-handle/{handle} returns the public profile of the user with the provided handle.
+// Handles are matched case-insensitively, and a leading "@" is ignored.
+func (cfg *ApiConfig) HandleGetUserByHandle(w http.ResponseWriter, r *http.Request) {
+	handle := strings.TrimPrefix(r.PathValue("handle"), "@")
+
+	dbUser, err := cfg.DbQueries.GetUserByHandle(r.Context(), handle)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, DatabaseUserToAPIProfile(dbUser))
+}
+
+// HandleGetUserResource is the handler registered for GET /api/users/{userID}/{resource}. It isn't an endpoint of its own:
+// it dispatches to GET /api/users/by-handle/{handle} (HandleGetUserByHandle) or GET /api/users/{userID}/mentions (HandleGetUserMentions).
+// Those two can't be registered separately because both would match "/api/users/by-handle/mentions", and ServeMux panics on
+// overlapping patterns where neither is more specific. A request matching neither gets a 404.
+func (cfg *ApiConfig) HandleGetUserResource(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.PathValue("userID") == "by-handle":
+		r.SetPathValue("handle", r.PathValue("resource"))
+		cfg.HandleGetUserByHandle(w, r)
+	case r.PathValue("resource") == "mentions":
+		cfg.HandleGetUserMentions(w, r)
+	default:
+		respondWithError(w, http.StatusNotFound, "not found", nil)
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// GET /api/users/{userID}/mentions returns all chirps that mention the provided user, sorted by created_at in ascending order.
+// Authenticated viewers don't see chirps from users they have blocked or been blocked by, nor chirps by private accounts they don't follow.
+func (cfg *ApiConfig) HandleGetUserMentions(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := cfg.authenticateOptionalUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id", err)
+		return
+	}
+
+	dbChirps, err := cfg.DbQueries.GetChirpsMentioningUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirps", err)
+		return
+	}
+
+	dbChirps, err = cfg.filterChirpsForViewer(r.Context(), viewerID, dbChirps, false)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirps", err)
+		return
+	}
+
+	jsonChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), viewerID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirp entities", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jsonChirps)
+}
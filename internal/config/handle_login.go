@@ -40,6 +40,11 @@ func (cfg *ApiConfig) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	// check for user record
 	dbUser, err := cfg.DbQueries.GetUserByEmail(context.Background(), params.Email)
 	if err != nil {
+		details := map[string]string{"reason": "unknown email"}
+		if emailHash, ok := cfg.auditEmailHash(params.Email); ok {
+			details["email_hash"] = emailHash
+		}
+		cfg.recordAuditEvent(r, auditEvent{eventType: auditLogin, failed: true, details: details})
 		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", err)
 		return
 	}
@@ -47,10 +52,25 @@ func (cfg *ApiConfig) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	// check password matches the record
 	// return 401 Unauthorised if no match
 	if err := auth.CheckPasswordHash(params.Password, dbUser.HashedPassword); err != nil {
+		cfg.recordAuditEvent(r, auditEvent{
+			eventType: auditLogin,
+			failed:    true,
+			actorID:   dbUser.ID,
+			details:   map[string]string{"reason": "incorrect password"},
+		})
 		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", err)
 		return
 	}
 
+	// logging in during the deletion grace period restores the account
+	if dbUser.DeletedAt.Valid {
+		dbUser, err = cfg.DbQueries.CancelUserDeletion(r.Context(), dbUser.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not login user", fmt.Errorf("error cancelling account deletion: %w", err))
+			return
+		}
+	}
+
 	// create access token
 	accesTokenExpiration := ACCESS_TOKEN_EXPIRATION
 	accessToken, err := auth.MakeJWT(
@@ -82,6 +102,8 @@ func (cfg *ApiConfig) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		RefreshToken string `json:"refresh_token"`
 	}
 
+	cfg.recordAuditEvent(r, auditEvent{eventType: auditLogin, actorID: dbUser.ID})
+
 	jsonLoginResponse := LoginResponse{
 		User:         DatabaseUserToAPIUser(dbUser),
 		Token:        accessToken,
@@ -0,0 +1,281 @@
+package config
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/stream"
+)
+
+// maximum number of flagged chirps or accounts returned by a moderation queue request
+const maxModerationQueueItems = 100
+
+// A ModeratedChirp is a chirp flagged by the spam pipeline, along with why it was flagged.
+type ModeratedChirp struct {
+	Chirp     Chirp     `json:"chirp"`
+	Status    string    `json:"status"`
+	Score     float64   `json:"score"`
+	Reasons   string    `json:"reasons"`
+	FlaggedAt time.Time `json:"flagged_at"`
+}
+
+// A ModeratedUser is an account flagged by the spam pipeline at signup, along with why it was flagged.
+type ModeratedUser struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	Handle    string    `json:"handle,omitempty"`
+	Status    string    `json:"status"`
+	Score     float64   `json:"score"`
+	Reasons   string    `json:"reasons"`
+	FlaggedAt time.Time `json:"flagged_at"`
+}
+
+// parseModerationStatus reads the optional status query parameter of a moderation queue request, which defaults to "queued".
+func parseModerationStatus(w http.ResponseWriter, r *http.Request) (status string, ok bool) {
+	status = r.URL.Query().Get("status")
+	switch status {
+	case "":
+		return moderationStatusQueued, true
+	case moderationStatusQueued, moderationStatusHidden:
+		return status, true
+	default:
+		respondWithError(w, http.StatusBadRequest, "status must be queued or hidden", nil)
+		return "", false
+	}
+}
+
+// GET /admin/moderation/chirps lists the chirps flagged by the spam pipeline, oldest first, up to 100 at a time.
+// The optional status query parameter picks "queued" chirps waiting for review (the default) or "hidden" chirps that were shadow-hidden.
+// Requires the ADMIN_KEY in an "Authorization: ApiKey <key>" header.
+func (cfg *ApiConfig) HandleGetModeratedChirps(w http.ResponseWriter, r *http.Request) {
+	if !cfg.authenticateAdmin(w, r) {
+		return // helper already wrote the error response
+	}
+	status, ok := parseModerationStatus(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	flagged, err := cfg.DbQueries.GetChirpModerationQueue(r.Context(), database.GetChirpModerationQueueParams{
+		Status:   status,
+		MaxItems: maxModerationQueueItems,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get moderation queue", err)
+		return
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(flagged))
+	for _, f := range flagged {
+		chirpIDs = append(chirpIDs, f.ChirpID)
+	}
+	dbChirps, err := cfg.DbQueries.GetChirpsByIDs(r.Context(), chirpIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get moderation queue", err)
+		return
+	}
+	jsonChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), uuid.Nil, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not load chirp entities", err)
+		return
+	}
+	chirpsByID := make(map[uuid.UUID]Chirp, len(jsonChirps))
+	for _, c := range jsonChirps {
+		chirpsByID[c.Id] = c
+	}
+
+	moderated := make([]ModeratedChirp, 0, len(flagged))
+	for _, f := range flagged {
+		chirp, found := chirpsByID[f.ChirpID]
+		if !found {
+			continue // e.g. a scheduled chirp that hasn't been published yet
+		}
+		moderated = append(moderated, ModeratedChirp{
+			Chirp:     chirp,
+			Status:    f.Status,
+			Score:     f.Score,
+			Reasons:   f.Reasons,
+			FlaggedAt: f.CreatedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, moderated)
+}
+
+// POST /admin/moderation/chirps/{chirpID}/approve clears a chirp's flag, making it visible to its audience.
+// It responds with a 204 status code, or a 404 if the chirp isn't flagged. Requires the ADMIN_KEY.
+func (cfg *ApiConfig) HandleApproveModeratedChirp(w http.ResponseWriter, r *http.Request) {
+	if !cfg.authenticateAdmin(w, r) {
+		return // helper already wrote the error response
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id", err)
+		return
+	}
+
+	approved, err := cfg.DbQueries.DeleteChirpModeration(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not approve chirp", err)
+		return
+	}
+	if approved == 0 {
+		respondWithError(w, http.StatusNotFound, "flagged chirp not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /admin/moderation/chirps/{chirpID}/reject deletes a flagged chirp. It stays flagged, so it remains hidden if its author restores it.
+// It responds with a 204 status code, or a 404 if the chirp isn't flagged. Requires the ADMIN_KEY.
+func (cfg *ApiConfig) HandleRejectModeratedChirp(w http.ResponseWriter, r *http.Request) {
+	if !cfg.authenticateAdmin(w, r) {
+		return // helper already wrote the error response
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id", err)
+		return
+	}
+
+	if _, err := cfg.DbQueries.GetChirpModeration(r.Context(), chirpID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "flagged chirp not found", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not reject chirp", err)
+		}
+		return
+	}
+
+	// the chirp may already have been deleted by its author
+	dbChirp, err := cfg.DbQueries.DeleteChirpById(r.Context(), chirpID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "could not reject chirp", err)
+		return
+	}
+	if err == nil {
+		cfg.recordAuditEvent(r, auditEvent{
+			eventType: auditChirpDelete,
+			targetID:  chirpID,
+			details:   map[string]string{"reason": "rejected by moderator", "author_id": dbChirp.UserID.String()},
+		})
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /admin/moderation/users lists the accounts flagged by the spam pipeline at signup, oldest first, up to 100 at a time.
+// The optional status query parameter picks "queued" accounts waiting for review (the default) or "hidden" accounts that were shadow-hidden.
+// Requires the ADMIN_KEY in an "Authorization: ApiKey <key>" header.
+func (cfg *ApiConfig) HandleGetModeratedUsers(w http.ResponseWriter, r *http.Request) {
+	if !cfg.authenticateAdmin(w, r) {
+		return // helper already wrote the error response
+	}
+	status, ok := parseModerationStatus(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	flagged, err := cfg.DbQueries.GetUserModerationQueue(r.Context(), database.GetUserModerationQueueParams{
+		Status:   status,
+		MaxItems: maxModerationQueueItems,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get moderation queue", err)
+		return
+	}
+
+	moderated := make([]ModeratedUser, 0, len(flagged))
+	for _, f := range flagged {
+		moderated = append(moderated, ModeratedUser{
+			UserID:    f.UserID,
+			Email:     f.Email,
+			Handle:    f.Handle.String,
+			Status:    f.Status,
+			Score:     f.Score,
+			Reasons:   f.Reasons,
+			FlaggedAt: f.CreatedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, moderated)
+}
+
+// POST /admin/moderation/users/{userID}/approve clears an account's flag, making its chirps visible to their audiences.
+// It responds with a 204 status code, or a 404 if the account isn't flagged. Requires the ADMIN_KEY.
+func (cfg *ApiConfig) HandleApproveModeratedUser(w http.ResponseWriter, r *http.Request) {
+	if !cfg.authenticateAdmin(w, r) {
+		return // helper already wrote the error response
+	}
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id", err)
+		return
+	}
+
+	approved, err := cfg.DbQueries.DeleteUserModeration(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not approve user", err)
+		return
+	}
+	if approved == 0 {
+		respondWithError(w, http.StatusNotFound, "flagged user not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /admin/moderation/users/{userID}/reject deletes a flagged account, which is purged along with its chirps once the deletion grace period has passed.
+// It stays flagged, so its chirps remain hidden if the account is restored. It responds with a 204 status code, or a 404 if the account isn't flagged.
+// Requires the ADMIN_KEY.
+func (cfg *ApiConfig) HandleRejectModeratedUser(w http.ResponseWriter, r *http.Request) {
+	if !cfg.authenticateAdmin(w, r) {
+		return // helper already wrote the error response
+	}
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id", err)
+		return
+	}
+
+	if _, err := cfg.DbQueries.GetUserModeration(r.Context(), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "flagged user not found", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not reject user", err)
+		}
+		return
+	}
+
+	// delete the account the same way DELETE /api/users/me does
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not reject user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	if _, err := qtx.SoftDeleteUser(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not reject user", err)
+		return
+	}
+	if err := qtx.RevokeAllRefreshTokensForUser(r.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not reject user", fmt.Errorf("error revoking refresh tokens: %w", err))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not reject user", err)
+		return
+	}
+	cfg.publishUserEvent(r.Context(), stream.UserUpdated, userID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"net/http"
+
+	"github.com/rickNoise/chirpy/internal/database"
+)
+
+// POST /api/users/{userID}/mute mutes a user for the authenticated user.
+// The muted user's chirps are left out of the muter's timeline and search results, but can still be viewed directly. Muting an already muted user is a no-op.
+// On success it responds with a 204 status code.
+func (cfg *ApiConfig) HandleMuteUser(w http.ResponseWriter, r *http.Request) {
+	muterID, mutedID, ok := cfg.parseUserRelationshipRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	err := cfg.DbQueries.CreateMute(r.Context(), database.CreateMuteParams{
+		MuterID: muterID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		if checkForForeignKeyConstraintViolationPostgresql(err) {
+			respondWithError(w, http.StatusNotFound, "user not found", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not mute user", err)
+		}
+		return
+	}
+	cfg.publishRelationshipsChanged(r.Context(), muterID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /api/users/{userID}/mute unmutes a user. It responds with a 204 status code, or 404 if the user wasn't muted.
+func (cfg *ApiConfig) HandleUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	muterID, mutedID, ok := cfg.parseUserRelationshipRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	rows, err := cfg.DbQueries.DeleteMute(r.Context(), database.DeleteMuteParams{
+		MuterID: muterID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not unmute user", err)
+		return
+	}
+	if rows == 0 {
+		respondWithError(w, http.StatusNotFound, "user is not muted", nil)
+		return
+	}
+	cfg.publishRelationshipsChanged(r.Context(), muterID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/users/me/mutes lists the users the authenticated user has muted, most recent first.
+func (cfg *ApiConfig) HandleGetMutes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbMutes, err := cfg.DbQueries.GetMutesByUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get mutes", err)
+		return
+	}
+
+	relationships := make([]userRelationship, 0, len(dbMutes))
+	for _, m := range dbMutes {
+		relationships = append(relationships, userRelationship{userID: m.MutedID, createdAt: m.CreatedAt})
+	}
+	jsonRelationships, err := cfg.userRelationshipsToAPI(r.Context(), relationships)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get mutes", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jsonRelationships)
+}
@@ -0,0 +1,306 @@
+package config
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/auth"
+	"github.com/rickNoise/chirpy/internal/chirptext"
+	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/stream"
+)
+
+// maximum lengths of profile fields, in characters
+const maxDisplayNameLength = 50
+const maxBioLength = 160
+
+// the members PATCH /api/users/me accepts
+var patchUserFields = []string{"email", "password", "current_password", "handle", "display_name", "bio", "avatar_media_id", "is_private"}
+
+// PATCH /api/users/me updates the authenticated user with JSON Merge Patch (RFC 7396) semantics:
+// members missing from the body are left unchanged, and members set to null are cleared.
+//
+//	{"display_name": "Lane", "bio": null, "avatar_media_id": "<uuid of an uploaded image>"}
+//
+// Changing the email or password also requires the user's current password in current_password; email and password cannot be cleared.
+// Changing the password revokes all of the user's refresh tokens, and a new refresh token for the current client is included in the response as refresh_token.
+// The avatar must be an image uploaded by the same user. Making a private account public (is_private: false) approves all of its pending follow requests. An email or handle already used by another user results in a 409 status code.
+// On success it responds with a 200 status code and the updated user.
+func (cfg *ApiConfig) HandlePatchUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	patch := map[string]json.RawMessage{}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		respondWithError(w, http.StatusBadRequest, "request body must be a json object", err)
+		return
+	}
+	for field := range patch {
+		if !slices.Contains(patchUserFields, field) {
+			respondWithError(w, http.StatusBadRequest, "unknown field: "+field, nil)
+			return
+		}
+	}
+
+	cfg.updateUser(w, r, userID, patch)
+}
+
+// updateUser applies a merge patch to a user and writes the response. It is shared by PATCH /api/users/me and PUT /api/users,
+// so that credentials are changed the same way by both: only with the current password, and revoking every refresh token on a password change.
+func (cfg *ApiConfig) updateUser(w http.ResponseWriter, r *http.Request, userID uuid.UUID, patch map[string]json.RawMessage) {
+	dbUser, err := cfg.DbQueries.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+
+	_, changingEmail := patch["email"]
+	_, changingPassword := patch["password"]
+	if err := checkCurrentPassword(patch, dbUser.HashedPassword); err != nil {
+		if errors.Is(err, errIncorrectCurrentPassword) {
+			failure := map[string]string{"reason": "incorrect current password"}
+			if changingEmail {
+				cfg.recordAuditEvent(r, auditEvent{eventType: auditEmailChange, failed: true, actorID: userID, targetID: userID, details: failure})
+			}
+			if changingPassword {
+				cfg.recordAuditEvent(r, auditEvent{eventType: auditPasswordChange, failed: true, actorID: userID, targetID: userID, details: failure})
+			}
+			respondWithError(w, http.StatusUnauthorized, err.Error(), err)
+		} else {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		}
+		return
+	}
+
+	// start from the current user and apply the patch
+	update := database.UpdateUserParams{
+		Email:          dbUser.Email,
+		HashedPassword: dbUser.HashedPassword,
+		Handle:         dbUser.Handle,
+		DisplayName:    dbUser.DisplayName,
+		Bio:            dbUser.Bio,
+		AvatarMediaID:  dbUser.AvatarMediaID,
+		IsPrivate:      dbUser.IsPrivate,
+		ID:             userID,
+	}
+	if err := applyUserPatch(&update, patch); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if raw, found := patch["avatar_media_id"]; found {
+		mediaIDString, null, err := mergePatchString(raw)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "avatar_media_id must be a string or null", err)
+			return
+		}
+		update.AvatarMediaID = uuid.NullUUID{}
+		if !null {
+			mediaID, err := uuid.Parse(mediaIDString)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "invalid avatar media id", err)
+				return
+			}
+			avatar, err := cfg.DbQueries.GetMedia(r.Context(), mediaID)
+			if err != nil || avatar.UserID != userID {
+				respondWithError(w, http.StatusBadRequest, "avatar media not found", err)
+				return
+			}
+			if avatar.ProcessingStatus == "failed" {
+				respondWithError(w, http.StatusBadRequest, "avatar media could not be processed", nil)
+				return
+			}
+			update.AvatarMediaID = uuid.NullUUID{UUID: avatar.ID, Valid: true}
+		}
+	}
+	// the update and any token revocation happen together, so a failed update never logs anyone out
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not update user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	dbUpdatedUser, err := qtx.UpdateUser(r.Context(), update)
+	if err != nil {
+		if checkForUniqueConstraintViolationPostgresql(err) {
+			respondWithError(w, http.StatusConflict, "user with that email or handle already exists", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not update user", err)
+		}
+		return
+	}
+
+	// an account that becomes public has nothing left to approve
+	var approvedFollowerIDs []uuid.UUID
+	if dbUser.IsPrivate && !dbUpdatedUser.IsPrivate {
+		approvedFollowerIDs, err = qtx.AcceptAllFollowRequests(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not update user", fmt.Errorf("error accepting follow requests: %w", err))
+			return
+		}
+	}
+
+	// a password change signs out every other session; the current client gets a fresh refresh token
+	var refreshToken string
+	if changingPassword {
+		if err := qtx.RevokeAllRefreshTokensForUser(r.Context(), userID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not update user", fmt.Errorf("error revoking refresh tokens: %w", err))
+			return
+		}
+		refreshToken, err = auth.MakeRefreshToken()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not update user", fmt.Errorf("error generating refresh token: %w", err))
+			return
+		}
+		_, err = qtx.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+			Token:     refreshToken,
+			UserID:    userID,
+			ExpiresAt: time.Now().Add(REFRESH_TOKEN_EXPIRATION),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not update user", fmt.Errorf("error storing refresh token in db: %w", err))
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not update user", err)
+		return
+	}
+
+	cfg.publishUserEvent(r.Context(), stream.UserUpdated, dbUpdatedUser.ID)
+	cfg.publishRelationshipsChanged(r.Context(), approvedFollowerIDs...)
+	if dbUpdatedUser.Email != dbUser.Email {
+		cfg.recordAuditEvent(r, auditEvent{eventType: auditEmailChange, actorID: userID, targetID: userID})
+	}
+	if changingPassword {
+		cfg.recordAuditEvent(r, auditEvent{eventType: auditPasswordChange, actorID: userID, targetID: userID})
+	}
+
+	type PatchUserResponse struct {
+		User                // anonymous embedding
+		RefreshToken string `json:"refresh_token,omitempty"`
+	}
+	respondWithJSON(w, http.StatusOK, PatchUserResponse{
+		User:         DatabaseUserToAPIUser(dbUpdatedUser),
+		RefreshToken: refreshToken,
+	})
+}
+
+var (
+	errMissingCurrentPassword   = errors.New("current_password is required to change email or password")
+	errIncorrectCurrentPassword = errors.New("incorrect current password")
+)
+
+// checkCurrentPassword makes sure a patch that changes the email or password carries the user's current password in current_password,
+// so credentials can only be changed by someone who knows the password, not just anyone holding an access token.
+func checkCurrentPassword(patch map[string]json.RawMessage, hashedPassword string) error {
+	_, changingEmail := patch["email"]
+	_, changingPassword := patch["password"]
+	if !changingEmail && !changingPassword {
+		return nil
+	}
+
+	currentPassword, _, err := mergePatchString(patch["current_password"])
+	if err != nil {
+		return errors.New("current_password must be a string")
+	}
+	if currentPassword == "" {
+		return errMissingCurrentPassword
+	}
+	if auth.CheckPasswordHash(currentPassword, hashedPassword) != nil {
+		return errIncorrectCurrentPassword
+	}
+	return nil
+}
+
+// applyUserPatch applies the members of a patch to update, except avatar_media_id, which has to be looked up.
+// The returned error describes the first invalid member, and is meant for the client.
+func applyUserPatch(update *database.UpdateUserParams, patch map[string]json.RawMessage) error {
+	if raw, found := patch["email"]; found {
+		email, null, err := mergePatchString(raw)
+		if err != nil || null || strings.TrimSpace(email) == "" {
+			return errors.New("invalid email provided")
+		}
+		update.Email = strings.TrimSpace(email)
+	}
+	if raw, found := patch["password"]; found {
+		password, null, err := mergePatchString(raw)
+		if err != nil || null || !validatePassword(password) {
+			return errors.New("invalid password provided")
+		}
+		update.HashedPassword, err = auth.HashPassword(password)
+		if err != nil {
+			return fmt.Errorf("invalid password provided: %w", err)
+		}
+	}
+	if raw, found := patch["handle"]; found {
+		handle, null, err := mergePatchString(raw)
+		if err != nil {
+			return errors.New("handle must be a string or null")
+		}
+		update.Handle = sql.NullString{}
+		if !null {
+			handle = strings.TrimPrefix(handle, "@")
+			if !chirptext.IsValidHandle(handle) {
+				return errors.New("invalid handle provided; use 1-15 letters, digits or underscores")
+			}
+			update.Handle = sql.NullString{String: handle, Valid: true}
+		}
+	}
+	if raw, found := patch["display_name"]; found {
+		displayName, _, err := mergePatchString(raw)
+		if err != nil {
+			return errors.New("display_name must be a string or null")
+		}
+		displayName = strings.TrimSpace(displayName)
+		if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+			return errors.New("display name is too long")
+		}
+		update.DisplayName = displayName
+	}
+	if raw, found := patch["bio"]; found {
+		bio, _, err := mergePatchString(raw)
+		if err != nil {
+			return errors.New("bio must be a string or null")
+		}
+		bio = strings.TrimSpace(bio)
+		if utf8.RuneCountInString(bio) > maxBioLength {
+			return errors.New("bio is too long")
+		}
+		update.Bio = bio
+	}
+	if raw, found := patch["is_private"]; found {
+		// null clears the flag, i.e. makes the account public
+		var isPrivate *bool
+		if err := json.Unmarshal(raw, &isPrivate); err != nil {
+			return errors.New("is_private must be a boolean or null")
+		}
+		update.IsPrivate = isPrivate != nil && *isPrivate
+	}
+	return nil
+}
+
+// mergePatchString decodes a JSON Merge Patch member that holds a string or null.
+// A missing member (nil raw) decodes as an empty string.
+func mergePatchString(raw json.RawMessage) (value string, null bool, err error) {
+	if raw == nil {
+		return "", false, nil
+	}
+	if string(raw) == "null" {
+		return "", true, nil
+	}
+	err = json.Unmarshal(raw, &value)
+	return value, false, err
+}
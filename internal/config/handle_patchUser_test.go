@@ -0,0 +1,101 @@
+package config
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/rickNoise/chirpy/internal/auth"
+	"github.com/rickNoise/chirpy/internal/database"
+)
+
+// testPatch decodes a PATCH /api/users/me body.
+func testPatch(t *testing.T, body string) map[string]json.RawMessage {
+	t.Helper()
+	patch := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(body), &patch); err != nil {
+		t.Fatalf("could not decode test patch %s: %v", body, err)
+	}
+	return patch
+}
+
+func TestApplyUserPatch(t *testing.T) {
+	current := database.UpdateUserParams{
+		Email:          "lane@example.com",
+		HashedPassword: "hash",
+		Handle:         sql.NullString{String: "lane", Valid: true},
+		DisplayName:    "Lane",
+		Bio:            "hello",
+		IsPrivate:      true,
+	}
+
+	update := current
+	if err := applyUserPatch(&update, testPatch(t, `{"display_name": " Lane W ", "bio": null, "handle": "@lanew", "is_private": null}`)); err != nil {
+		t.Fatalf("applyUserPatch: %v", err)
+	}
+	expected := current
+	expected.DisplayName = "Lane W"
+	expected.Bio = ""
+	expected.Handle = sql.NullString{String: "lanew", Valid: true}
+	expected.IsPrivate = false
+	if update != expected {
+		t.Errorf("got %+v, expected %+v", update, expected)
+	}
+
+	update = current
+	if err := applyUserPatch(&update, testPatch(t, `{"handle": null}`)); err != nil || update.Handle.Valid {
+		t.Errorf("expected null to clear the handle, got %+v, %v", update.Handle, err)
+	}
+
+	update = current
+	if err := applyUserPatch(&update, testPatch(t, `{"password": "new password"}`)); err != nil {
+		t.Fatalf("applyUserPatch: %v", err)
+	}
+	if auth.CheckPasswordHash("new password", update.HashedPassword) != nil {
+		t.Errorf("expected the new password to be hashed")
+	}
+
+	for _, body := range []string{
+		`{"email": null}`,
+		`{"email": "  "}`,
+		`{"password": null}`,
+		`{"password": ""}`,
+		`{"handle": "not a handle"}`,
+		`{"handle": 42}`,
+		`{"bio": ["hello"]}`,
+		`{"is_private": "yes"}`,
+	} {
+		update := current
+		if err := applyUserPatch(&update, testPatch(t, body)); err == nil {
+			t.Errorf("expected %s to be rejected", body)
+		}
+	}
+}
+
+func TestCheckCurrentPassword(t *testing.T) {
+	hashed, err := auth.HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("could not hash test password: %v", err)
+	}
+
+	cases := []struct {
+		body     string
+		expected error
+	}{
+		{body: `{"bio": "no credentials changed"}`, expected: nil},
+		{body: `{"email": "new@example.com"}`, expected: errMissingCurrentPassword},
+		{body: `{"password": "new", "current_password": null}`, expected: errMissingCurrentPassword},
+		{body: `{"password": "new", "current_password": "battery staple"}`, expected: errIncorrectCurrentPassword},
+		{body: `{"email": "new@example.com", "current_password": "correct horse"}`, expected: nil},
+	}
+	for _, c := range cases {
+		if err := checkCurrentPassword(testPatch(t, c.body), hashed); !errors.Is(err, c.expected) {
+			t.Errorf("%s: got %v, expected %v", c.body, err, c.expected)
+		}
+	}
+
+	if err := checkCurrentPassword(testPatch(t, `{"email": "new@example.com", "current_password": 1}`), hashed); err == nil {
+		t.Errorf("expected a non-string current_password to be rejected")
+	}
+}
@@ -29,18 +29,23 @@ func (cfg *ApiConfig) HandleRefresh(w http.ResponseWriter, r *http.Request) {
 
 	dbRefreshToken, err := cfg.DbQueries.GetRefreshTokenByTokenString(context.Background(), tokenString)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "", err)
+		cfg.recordAuditEvent(r, auditEvent{eventType: auditRefresh, failed: true, details: map[string]string{"reason": "unknown token"}})
+		respondWithError(w, http.StatusUnauthorized, "", fmt.Errorf("unknown refresh token: %w", err))
 		return
 	}
 
 	// make sure token is not expired
 	if time.Now().After(dbRefreshToken.ExpiresAt) {
-		respondWithError(w, http.StatusUnauthorized, "", fmt.Errorf("provided refresh token is expired: %v", dbRefreshToken))
+		cfg.recordAuditEvent(r, auditEvent{eventType: auditRefresh, failed: true, actorID: dbRefreshToken.UserID, details: map[string]string{"reason": "expired token"}})
+		respondWithError(w, http.StatusUnauthorized, "", fmt.Errorf("refresh token for user %s is expired", dbRefreshToken.UserID))
+		return
 	}
 
 	// if the revoked_at field in the db has a timestampe, we cannot accept this token
 	if dbRefreshToken.RevokedAt.Valid {
-		respondWithError(w, http.StatusUnauthorized, "", fmt.Errorf("provided refresh token has been revoked: %v", dbRefreshToken))
+		cfg.recordAuditEvent(r, auditEvent{eventType: auditRefresh, failed: true, actorID: dbRefreshToken.UserID, details: map[string]string{"reason": "revoked token"}})
+		respondWithError(w, http.StatusUnauthorized, "", fmt.Errorf("refresh token for user %s has been revoked", dbRefreshToken.UserID))
+		return
 	}
 
 	// generate a new refresh token to include in response for the user requesting
@@ -52,8 +57,11 @@ func (cfg *ApiConfig) HandleRefresh(w http.ResponseWriter, r *http.Request) {
 	)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "error refreshing access token", err)
+		return
 	}
 
+	cfg.recordAuditEvent(r, auditEvent{eventType: auditRefresh, actorID: requestingUser})
+
 	type RefreshResponse struct {
 		Token string `json:"token"`
 	}
@@ -4,6 +4,7 @@ import "net/http"
 
 func (cfg *ApiConfig) ResetHandler(w http.ResponseWriter, r *http.Request) {
 	if cfg.Platform != "dev" {
+		cfg.recordAuditEvent(r, auditEvent{eventType: auditAdminReset, failed: true, details: map[string]string{"reason": "not a dev environment"}})
 		respondWithError(w, http.StatusForbidden, "cannot reset all user data in a non-dev environment", nil)
 		return
 	}
@@ -11,6 +12,8 @@ func (cfg *ApiConfig) ResetHandler(w http.ResponseWriter, r *http.Request) {
 	err := cfg.DbQueries.DeleteAllUsers(r.Context())
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "could not delete user data", err)
+		return
 	}
+	cfg.recordAuditEvent(r, auditEvent{eventType: auditAdminReset})
 	respondWithJSON(w, http.StatusOK, struct{}{})
 }
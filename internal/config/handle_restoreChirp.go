@@ -0,0 +1,69 @@
+package config
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/stream"
+)
+
+// how long a deleted chirp can be restored before it is purged
+const chirpRetentionPeriod = 30 * 24 * time.Hour
+
+// POST /api/chirps/{chirpID}/restore restores one of the authenticated user's deleted chirps.
+// Only the author can restore a chirp, and only within 30 days of deleting it; after that it responds with a 410 status code.
+// On success it responds with a 200 status code and the restored chirp.
+func (cfg *ApiConfig) HandleRestoreChirp(w http.ResponseWriter, r *http.Request) {
+	requestingUserID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	chirpUUID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id", err)
+		return
+	}
+
+	dbChirp, err := cfg.DbQueries.GetChirpIncludingDeleted(r.Context(), chirpUUID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "chirp not found", err)
+		return
+	}
+	if requestingUserID != dbChirp.UserID {
+		respondWithError(w, http.StatusForbidden, "", nil)
+		return
+	}
+	if !dbChirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusConflict, "chirp is not deleted", nil)
+		return
+	}
+	if time.Since(dbChirp.DeletedAt.Time) > chirpRetentionPeriod {
+		respondWithError(w, http.StatusGone, "chirp can no longer be restored", nil)
+		return
+	}
+
+	dbRestoredChirp, err := cfg.DbQueries.RestoreChirp(r.Context(), chirpUUID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not restore chirp", err)
+		return
+	}
+
+	jsonChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), requestingUserID, []database.Chirp{dbRestoredChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirp entities", err)
+		return
+	}
+
+	// the event is broadcast, so it must not carry poll results only the author can see
+	eventChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), uuid.Nil, []database.Chirp{dbRestoredChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirp entities", err)
+		return
+	}
+	cfg.publishChirpEvent(r.Context(), stream.ChirpRestored, dbRestoredChirp, eventChirps[0])
+
+	respondWithJSON(w, http.StatusOK, jsonChirps[0])
+}
@@ -16,11 +16,14 @@ func (cfg *ApiConfig) HandleRevoke(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = cfg.DbQueries.RevokeRefreshToken(context.Background(), tokenString)
+	dbRefreshToken, err := cfg.DbQueries.RevokeRefreshToken(context.Background(), tokenString)
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "", err)
+		cfg.recordAuditEvent(r, auditEvent{eventType: auditRevoke, failed: true, details: map[string]string{"reason": "unknown token"}})
+		respondWithError(w, http.StatusNotFound, "", fmt.Errorf("unknown refresh token: %w", err))
 		return
 	}
 
+	cfg.recordAuditEvent(r, auditEvent{eventType: auditRevoke, actorID: dbRefreshToken.UserID})
+
 	w.WriteHeader(http.StatusNoContent)
 }
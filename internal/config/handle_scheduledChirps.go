@@ -0,0 +1,228 @@
+package config
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/chirptext"
+	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/spam"
+)
+
+// how far ahead a chirp can be scheduled
+const maxChirpScheduleAhead = 365 * 24 * time.Hour
+
+// validateChirpPublishAt checks a requested publish time; the error message is suitable for the requester.
+func validateChirpPublishAt(publishAt time.Time) error {
+	if !publishAt.After(time.Now()) {
+		return errors.New("publish_at must be in the future")
+	}
+	if publishAt.After(time.Now().Add(maxChirpScheduleAhead)) {
+		return errors.New("publish_at must be within a year")
+	}
+	return nil
+}
+
+// GET /api/scheduled-chirps lists the authenticated user's scheduled chirps, soonest first.
+// Each chirp includes its publish_at; once published, a chirp no longer has one and appears in the usual read endpoints.
+func (cfg *ApiConfig) HandleGetScheduledChirps(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbChirps, err := cfg.DbQueries.GetScheduledChirpsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get scheduled chirps", err)
+		return
+	}
+	jsonChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), userID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get scheduled chirps", err)
+		return
+	}
+	if jsonChirps == nil {
+		jsonChirps = []Chirp{}
+	}
+
+	respondWithJSON(w, http.StatusOK, jsonChirps)
+}
+
+// PATCH /api/scheduled-chirps/{chirpID} edits one of the authenticated user's scheduled chirps.
+// It accepts any of body, visibility and publish_at, validated as for POST /api/chirps; omitted fields are left unchanged. A new body is also scored by the spam pipeline.
+// It responds with a 200 status code and the updated chirp, or 404 if there is no such scheduled chirp (including one that has already been published).
+func (cfg *ApiConfig) HandleUpdateScheduledChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id", err)
+		return
+	}
+
+	type parameters struct {
+		Body       *string    `json:"body"`
+		Visibility *string    `json:"visibility"`
+		PublishAt  *time.Time `json:"publish_at"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "error decoding req json body", err)
+		return
+	}
+
+	dbChirp, err := cfg.DbQueries.GetScheduledChirp(r.Context(), database.GetScheduledChirpParams{
+		ID:     chirpID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "scheduled chirp not found", err)
+		return
+	}
+
+	update := database.UpdateScheduledChirpParams{
+		Body:       dbChirp.Body,
+		Visibility: dbChirp.Visibility,
+		PublishAt:  dbChirp.PublishAt,
+		ID:         dbChirp.ID,
+		UserID:     userID,
+	}
+	if params.Body != nil {
+		*params.Body = chirptext.Normalize(*params.Body)
+		if err := validateChirpBody(*params.Body); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		_, update.Body = censorChirp(*params.Body)
+	}
+
+	// a new body is scored like a new chirp, so a harmless scheduled chirp can't be edited into spam
+	verdict := spam.Verdict{Action: spam.ActionAllow}
+	if update.Body != dbChirp.Body {
+		dbUser, err := cfg.DbQueries.GetUserById(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "user not found", err)
+			return
+		}
+		verdict, ok = cfg.checkChirpSpam(w, r, dbUser, update.Body)
+		if !ok {
+			return // helper already wrote the error response
+		}
+	}
+	if params.Visibility != nil {
+		if !slices.Contains(chirpVisibilities, *params.Visibility) {
+			respondWithError(w, http.StatusBadRequest, "visibility must be one of public, followers, mentioned or unlisted", nil)
+			return
+		}
+		update.Visibility = *params.Visibility
+	}
+	if params.PublishAt != nil {
+		if err := validateChirpPublishAt(*params.PublishAt); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		update.PublishAt = sql.NullTime{Time: params.PublishAt.UTC(), Valid: true}
+	}
+
+	// the chirp and its re-parsed entities are updated together
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not update scheduled chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	dbUpdatedChirp, err := qtx.UpdateScheduledChirp(r.Context(), update)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// published by the scheduler in the meantime
+			respondWithError(w, http.StatusNotFound, "scheduled chirp not found", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not update scheduled chirp", err)
+		}
+		return
+	}
+	if dbUpdatedChirp.Body != dbChirp.Body {
+		if err := qtx.DeleteHashtagsForChirp(r.Context(), dbChirp.ID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not update scheduled chirp", err)
+			return
+		}
+		if err := qtx.DeleteMentionsForChirp(r.Context(), dbChirp.ID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not update scheduled chirp", err)
+			return
+		}
+		if err := qtx.DeleteChirpLinkPreview(r.Context(), dbChirp.ID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not update scheduled chirp", err)
+			return
+		}
+		if err := storeChirpEntities(r.Context(), qtx, dbUpdatedChirp); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not update scheduled chirp", err)
+			return
+		}
+		if err := flagChirp(r.Context(), qtx, dbUpdatedChirp.ID, verdict); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not update scheduled chirp", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not update scheduled chirp", err)
+		return
+	}
+	cfg.enqueueChirpLinkPreview(dbUpdatedChirp)
+
+	jsonChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), userID, []database.Chirp{dbUpdatedChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not load chirp entities", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jsonChirps[0])
+}
+
+// DELETE /api/scheduled-chirps/{chirpID} cancels one of the authenticated user's scheduled chirps, permanently deleting it along with its media.
+// It responds with a 204 status code, or 404 if there is no such scheduled chirp (including one that has already been published).
+func (cfg *ApiConfig) HandleCancelScheduledChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id", err)
+		return
+	}
+
+	// blobs live outside the database, so their keys are looked up before the chirp (and its media rows) are deleted
+	blobKeys, err := cfg.chirpBlobKeys(r.Context(), []uuid.UUID{chirpID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not cancel scheduled chirp", err)
+		return
+	}
+
+	rows, err := cfg.DbQueries.DeleteScheduledChirp(r.Context(), database.DeleteScheduledChirpParams{
+		ID:     chirpID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not cancel scheduled chirp", err)
+		return
+	}
+	if rows == 0 {
+		respondWithError(w, http.StatusNotFound, "scheduled chirp not found", nil)
+		return
+	}
+
+	cfg.deleteBlobs(r.Context(), blobKeys)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/logging"
+	"github.com/rickNoise/chirpy/internal/stream"
+)
+
+// how often a comment line is sent to keep idle connections (and any proxies in between) open
+const streamHeartbeatInterval = 15 * time.Second
+
+// GET /api/stream pushes chirp.created, chirp.deleted and chirp.restored events to the client as Server-Sent Events.
+// It accepts an optional author_id query parameter to only receive events for that author's chirps.
+// The request may be authenticated; events are only sent for chirps the viewer is allowed to see, following the same rules as GET /api/chirps.
+// The viewer's blocks, mutes and follows are reloaded whenever they change, and the stream ends if the viewer's account is deleted.
+//
+// Each event carries an id; clients reconnecting with a Last-Event-ID header receive any events they missed, as long as they are still in the hub's recent history.
+// If the client falls too far behind, the server ends the stream and the client is expected to reconnect.
+func (cfg *ApiConfig) HandleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok || cfg.Stream == nil {
+		respondWithError(w, http.StatusInternalServerError, "streaming unsupported", nil)
+		return
+	}
+
+	viewerID, ok := cfg.authenticateOptionalUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	dbViewer, err := cfg.loadChirpViewer(r.Context(), viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not load blocks, mutes and follows", err)
+		return
+	}
+	viewer := newLiveViewer(dbViewer)
+
+	// check for optional author_id query parameter.
+	authorID := uuid.Nil
+	if rawAuthorID := r.URL.Query().Get("author_id"); rawAuthorID != "" {
+		authorID, err = uuid.Parse(rawAuthorID)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid author_id provided", err)
+			return
+		}
+	}
+	// without an author_id the stream is a timeline, and leaves out muted users and unlisted chirps like GET /api/chirps does
+	filter := func(e stream.Event) bool {
+		if !stream.IsChirpEvent(e) {
+			return viewer.isViewerEvent(e)
+		}
+		if authorID == uuid.Nil {
+			return viewer.canSee(chirpEventAudience(e), true)
+		}
+		return e.AuthorID == authorID && viewer.canSee(chirpEventAudience(e), false)
+	}
+
+	// browsers send Last-Event-ID automatically when an EventSource reconnects
+	var lastEventID uint64
+	if rawLastEventID := r.Header.Get("Last-Event-ID"); rawLastEventID != "" {
+		parsed, err := strconv.ParseUint(rawLastEventID, 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid Last-Event-ID header", err)
+			return
+		}
+		lastEventID = parsed
+	}
+
+	sub, _ := cfg.Stream.Subscribe(filter, lastEventID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case e, open := <-sub.Events():
+			if !open {
+				// dropped as a slow consumer, or shutting down; the client will reconnect
+				return
+			}
+			if viewer.isViewerEvent(e) {
+				// if the viewer can't be reloaded, end the stream rather than keep delivering with stale blocks; the client will reconnect
+				active, err := cfg.reloadLiveViewer(r.Context(), viewer)
+				if err != nil {
+					logging.FromContext(r.Context()).Error("could not reload stream viewer", "error", err)
+				}
+				if !active {
+					return
+				}
+				continue
+			}
+			if !filter(e) {
+				continue // the viewer's relationships changed since the event was queued
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
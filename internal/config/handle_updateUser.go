@@ -1,22 +1,22 @@
 package config
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
-
-	"github.com/rickNoise/chirpy/internal/auth"
-	"github.com/rickNoise/chirpy/internal/database"
 )
 
 // Add a PUT /api/users endpoint so that users can update their own (but not others') email and password. It requires:
 // *An access token in the header
 // *A new password and email in the request body
+// *The user's current password in current_password
 // The request must have BOTH an email and a passowrd, they are both required.
+// It is applied like a PATCH /api/users/me of the same fields, so all of the user's refresh tokens are revoked and a new one is returned as refresh_token.
+// Requiring current_password (and revoking the refresh tokens) is a breaking change for clients written against the original endpoint, which only needed the access token.
 func (cfg *ApiConfig) HandleUpdateUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Password string `json:"password"`
-		Email    string `json:"email"`
+		Password        string `json:"password"`
+		Email           string `json:"email"`
+		CurrentPassword string `json:"current_password"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -37,24 +37,13 @@ func (cfg *ApiConfig) HandleUpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(params.Password)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "problem updating password", err)
-		return
-	}
-
-	dbUpdatedUser, err := cfg.DbQueries.UpdateEmailAndPasswordByUserId(
-		context.Background(),
-		database.UpdateEmailAndPasswordByUserIdParams{
-			Newemail:          params.Email,
-			Newhashedpassword: hashedPassword,
-			Userid:            userID,
-		},
-	)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "could not update user", err)
-		return
+	patch := map[string]json.RawMessage{}
+	for field, value := range map[string]string{"email": params.Email, "password": params.Password, "current_password": params.CurrentPassword} {
+		if patch[field], err = json.Marshal(value); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "could not update user", err)
+			return
+		}
 	}
 
-	respondWithJSON(w, http.StatusOK, DatabaseUserToAPIUser(dbUpdatedUser))
+	cfg.updateUser(w, r, userID, patch)
 }
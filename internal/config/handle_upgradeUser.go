@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/rickNoise/chirpy/internal/auth"
+	"github.com/rickNoise/chirpy/internal/stream"
 )
 
 // Update the POST /api/polka/webhooks endpoint.
@@ -30,6 +31,7 @@ func (cfg *ApiConfig) HandleUpgradeUser(w http.ResponseWriter, r *http.Request)
 	// First ensure the API key in the haeader matches the one we have in config
 	headerKey, err := auth.GetAPIKey(r.Header)
 	if err != nil || headerKey != cfg.PolkaKey {
+		cfg.recordAuditEvent(r, auditEvent{eventType: auditUpgrade, failed: true, details: map[string]string{"reason": "invalid api key"}})
 		respondWithError(w, http.StatusUnauthorized, "", err)
 		return
 	}
@@ -67,6 +69,9 @@ func (cfg *ApiConfig) HandleUpgradeUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	cfg.publishUserEvent(r.Context(), stream.UserUpgraded, params.Data.UserID)
+	cfg.recordAuditEvent(r, auditEvent{eventType: auditUpgrade, targetID: params.Data.UserID, details: map[string]string{"source": "polka"}})
+
 	// if user is upgraded successfully, respond with 204 No Content and an empty response body
 	w.WriteHeader(http.StatusNoContent)
 }
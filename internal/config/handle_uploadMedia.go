@@ -0,0 +1,86 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/media"
+)
+
+// allowance for the multipart framing around the uploaded file
+const multipartOverheadBytes = 64 << 10
+
+// POST /api/media uploads an image as multipart/form-data in a field called "file".
+// This is an authenticated endpoint. The content type is sniffed from the uploaded bytes, and only png, jpeg, gif and webp images up to 5 MiB and 8192x8192 pixels are accepted.
+// On success it responds with a 201 status code and the media resource, whose id can then be attached to a chirp via the media_ids field of POST /api/chirps.
+// The upload is then processed in the background (metadata stripped, thumbnails and blurhash generated); its status field reports progress.
+func (cfg *ApiConfig) HandleUploadMedia(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, media.MaxUploadBytes+multipartOverheadBytes)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "file is too large", err)
+		} else {
+			respondWithError(w, http.StatusBadRequest, "expected a multipart form with a file field", err)
+		}
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, media.MaxUploadBytes+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "could not read uploaded file", err)
+		return
+	}
+	if len(data) > media.MaxUploadBytes {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "file is too large", nil)
+		return
+	}
+
+	info, err := media.Inspect(data)
+	if err != nil {
+		if errors.Is(err, media.ErrUnsupportedType) {
+			respondWithError(w, http.StatusUnsupportedMediaType, err.Error(), err)
+		} else {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		}
+		return
+	}
+
+	// the storage key is derived from the media id, never from the client's file name
+	mediaID := uuid.New()
+	storageKey := mediaID.String() + info.Extension
+	if err := cfg.Blobs.Put(r.Context(), storageKey, bytes.NewReader(data), int64(len(data)), info.ContentType); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not store media", err)
+		return
+	}
+
+	dbMedia, err := cfg.DbQueries.CreateMedia(r.Context(), database.CreateMediaParams{
+		ID:          mediaID,
+		UserID:      userID,
+		StorageKey:  storageKey,
+		ContentType: info.ContentType,
+		SizeBytes:   int64(len(data)),
+		Width:       int32(info.Width),
+		Height:      int32(info.Height),
+	})
+	if err != nil {
+		cfg.Blobs.Delete(r.Context(), storageKey)
+		respondWithError(w, http.StatusInternalServerError, "could not store media", err)
+		return
+	}
+
+	cfg.enqueueMedia(dbMedia.ID)
+
+	respondWithJSON(w, http.StatusCreated, DatabaseMediaToAPIMedia(dbMedia))
+}
@@ -0,0 +1,450 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+)
+
+// POST /api/lists creates a named list of accounts for the authenticated user.
+//
+//	{"name": "Go team", "description": "gophers I follow", "is_public": true}
+//
+// Lists are private unless is_public is set. A user's list names must be unique; a duplicate results in a 409 status code.
+// On success it responds with a 201 status code and the list.
+func (cfg *ApiConfig) HandleCreateUserList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	type parameters struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		IsPublic    bool   `json:"is_public"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "error decoding req json body", err)
+		return
+	}
+	name, description, msg := validateListFields(params.Name, params.Description)
+	if msg != "" {
+		respondWithError(w, http.StatusBadRequest, msg, nil)
+		return
+	}
+
+	dbList, err := cfg.DbQueries.CreateUserList(r.Context(), database.CreateUserListParams{
+		UserID:      userID,
+		Name:        name,
+		Description: description,
+		IsPublic:    params.IsPublic,
+	})
+	if err != nil {
+		if checkForUniqueConstraintViolationPostgresql(err) {
+			respondWithError(w, http.StatusConflict, "you already have a list with that name", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not create list", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, DatabaseUserListToAPIUserList(dbList))
+}
+
+// GET /api/lists lists the authenticated user's own lists, public and private, in alphabetical order.
+func (cfg *ApiConfig) HandleGetUserLists(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbLists, err := cfg.DbQueries.GetUserListsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get lists", err)
+		return
+	}
+	jsonLists, err := cfg.userListsToAPI(r.Context(), dbLists)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get lists", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jsonLists)
+}
+
+// GET /api/lists/subscriptions lists the public lists the authenticated user subscribes to, in alphabetical order.
+func (cfg *ApiConfig) HandleGetSubscribedUserLists(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbLists, err := cfg.DbQueries.GetSubscribedUserLists(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get lists", err)
+		return
+	}
+	jsonLists, err := cfg.userListsToAPI(r.Context(), dbLists)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get lists", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jsonLists)
+}
+
+// GET /api/lists/{listID} returns a list with its member and subscriber counts.
+// Public lists can be viewed by anyone; private ones respond with a 404 status code to anyone but their owner.
+func (cfg *ApiConfig) HandleGetUserList(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := cfg.authenticateOptionalUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	dbList, ok := cfg.loadUserList(w, r, viewerID, false)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	jsonLists, err := cfg.userListsToAPI(r.Context(), []database.UserList{dbList})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get list", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jsonLists[0])
+}
+
+// PATCH /api/lists/{listID} updates any of the name, description and is_public of one of the authenticated user's lists; omitted fields are left unchanged.
+// It responds with a 200 status code and the updated list.
+func (cfg *ApiConfig) HandleUpdateUserList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	dbList, ok := cfg.loadUserList(w, r, userID, true)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	type parameters struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		IsPublic    *bool   `json:"is_public"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "error decoding req json body", err)
+		return
+	}
+
+	update := database.UpdateUserListParams{
+		Name:        dbList.Name,
+		Description: dbList.Description,
+		IsPublic:    dbList.IsPublic,
+		ID:          dbList.ID,
+	}
+	if params.Name != nil {
+		update.Name = *params.Name
+	}
+	if params.Description != nil {
+		update.Description = *params.Description
+	}
+	if params.IsPublic != nil {
+		update.IsPublic = *params.IsPublic
+	}
+	var msg string
+	update.Name, update.Description, msg = validateListFields(update.Name, update.Description)
+	if msg != "" {
+		respondWithError(w, http.StatusBadRequest, msg, nil)
+		return
+	}
+
+	dbUpdatedList, err := cfg.DbQueries.UpdateUserList(r.Context(), update)
+	if err != nil {
+		if checkForUniqueConstraintViolationPostgresql(err) {
+			respondWithError(w, http.StatusConflict, "you already have a list with that name", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not update list", err)
+		}
+		return
+	}
+	jsonLists, err := cfg.userListsToAPI(r.Context(), []database.UserList{dbUpdatedList})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get list", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jsonLists[0])
+}
+
+// DELETE /api/lists/{listID} deletes one of the authenticated user's lists along with its memberships and subscriptions.
+// It responds with a 204 status code.
+func (cfg *ApiConfig) HandleDeleteUserList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	dbList, ok := cfg.loadUserList(w, r, userID, true)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	if err := cfg.DbQueries.DeleteUserList(r.Context(), dbList.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not delete list", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/lists/{listID}/members lists the members of a list visible to the viewer, most recently added first.
+func (cfg *ApiConfig) HandleGetUserListMembers(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := cfg.authenticateOptionalUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	dbList, ok := cfg.loadUserList(w, r, viewerID, false)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbMembers, err := cfg.DbQueries.GetUserListMembers(r.Context(), dbList.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get list members", err)
+		return
+	}
+
+	relationships := make([]userRelationship, 0, len(dbMembers))
+	for _, m := range dbMembers {
+		relationships = append(relationships, userRelationship{userID: m.UserID, createdAt: m.CreatedAt})
+	}
+	jsonRelationships, err := cfg.userRelationshipsToAPI(r.Context(), relationships)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get list members", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jsonRelationships)
+}
+
+// POST /api/lists/{listID}/members adds a user, given as {"user_id": "..."}, to one of the authenticated user's lists.
+// Adding an existing member is a no-op, and users who have blocked each other cannot be added. It responds with a 204 status code, or 404 if the user doesn't exist.
+func (cfg *ApiConfig) HandleAddUserListMember(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	dbList, ok := cfg.loadUserList(w, r, userID, true)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	type parameters struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "error decoding req json body", err)
+		return
+	}
+
+	if _, err := cfg.DbQueries.GetActiveUserById(r.Context(), params.UserID); err != nil {
+		respondWithError(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+	blocked, err := cfg.DbQueries.GetBlockRelatedUserIDs(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not add list member", err)
+		return
+	}
+	if slices.Contains(blocked, params.UserID) {
+		respondWithError(w, http.StatusForbidden, "cannot add this user", nil)
+		return
+	}
+
+	err = cfg.DbQueries.AddUserListMember(r.Context(), database.AddUserListMemberParams{
+		ListID: dbList.ID,
+		UserID: params.UserID,
+	})
+	if err != nil {
+		if checkForForeignKeyConstraintViolationPostgresql(err) {
+			respondWithError(w, http.StatusNotFound, "user not found", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "could not add list member", err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /api/lists/{listID}/members/{userID} removes a user from one of the authenticated user's lists.
+// It responds with a 204 status code, or 404 if the user wasn't a member.
+func (cfg *ApiConfig) HandleRemoveUserListMember(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	dbList, ok := cfg.loadUserList(w, r, userID, true)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid user id", err)
+		return
+	}
+
+	rows, err := cfg.DbQueries.RemoveUserListMember(r.Context(), database.RemoveUserListMemberParams{
+		ListID: dbList.ID,
+		UserID: memberID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not remove list member", err)
+		return
+	}
+	if rows == 0 {
+		respondWithError(w, http.StatusNotFound, "user is not a member of the list", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/lists/{listID}/subscribe subscribes the authenticated user to someone else's public list.
+// Subscribing again is a no-op. It responds with a 204 status code.
+func (cfg *ApiConfig) HandleSubscribeToUserList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	dbList, ok := cfg.loadUserList(w, r, userID, false)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	if dbList.UserID == userID {
+		respondWithError(w, http.StatusBadRequest, "cannot subscribe to your own list", nil)
+		return
+	}
+
+	err := cfg.DbQueries.SubscribeToUserList(r.Context(), database.SubscribeToUserListParams{
+		ListID: dbList.ID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not subscribe to list", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /api/lists/{listID}/subscribe unsubscribes the authenticated user from a list.
+// It responds with a 204 status code, or 404 if they weren't subscribed.
+func (cfg *ApiConfig) HandleUnsubscribeFromUserList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticateUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid list id", err)
+		return
+	}
+
+	// no visibility check, so a list that has since become private can still be unsubscribed from
+	rows, err := cfg.DbQueries.UnsubscribeFromUserList(r.Context(), database.UnsubscribeFromUserListParams{
+		ListID: listID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not unsubscribe from list", err)
+		return
+	}
+	if rows == 0 {
+		respondWithError(w, http.StatusNotFound, "not subscribed to the list", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/lists/{listID}/chirps is a timeline of chirps by the members of a list, newest first.
+// It returns a page of up to ?limit= chirps (default 20, at most 100) along with a next_cursor for the following page.
+// Like the main timeline, it leaves out chirps the viewer isn't allowed to see, users they have muted, and unlisted chirps.
+func (cfg *ApiConfig) HandleGetUserListChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := cfg.authenticateOptionalUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	dbList, ok := cfg.loadUserList(w, r, viewerID, false)
+	if !ok {
+		return // helper already wrote the error response
+	}
+	limit, cursor, ok := parsePageRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	dbChirps, err := cfg.DbQueries.GetUserListTimelinePage(r.Context(), database.GetUserListTimelinePageParams{
+		ListID:          dbList.ID,
+		BeforeCreatedAt: cursor.time,
+		BeforeID:        cursor.id,
+		MaxChirps:       limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get list chirps", err)
+		return
+	}
+
+	page := ChirpPage{}
+	// the cursor comes from the unfiltered page, so chirps hidden from this viewer aren't fetched again
+	if len(dbChirps) == int(limit) {
+		last := dbChirps[len(dbChirps)-1]
+		page.NextCursor = pageCursor{time: last.CreatedAt, id: last.ID}.String()
+	}
+
+	dbChirps, err = cfg.filterChirpsForViewer(r.Context(), viewerID, dbChirps, true)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get list chirps", err)
+		return
+	}
+	page.Chirps, err = cfg.databaseChirpsToAPIChirps(r.Context(), viewerID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get list chirps", err)
+		return
+	}
+	if page.Chirps == nil {
+		page.Chirps = []Chirp{}
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// loadUserList loads the {listID} list, writing an error response if it isn't visible to the viewer,
+// or if requireOwner is set and the viewer doesn't own it.
+func (cfg *ApiConfig) loadUserList(w http.ResponseWriter, r *http.Request, viewerID uuid.UUID, requireOwner bool) (database.UserList, bool) {
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid list id", err)
+		return database.UserList{}, false
+	}
+
+	dbList, err := cfg.DbQueries.GetUserList(r.Context(), listID)
+	if err != nil || (!dbList.IsPublic && dbList.UserID != viewerID) {
+		respondWithError(w, http.StatusNotFound, "list not found", err)
+		return database.UserList{}, false
+	}
+	if requireOwner && dbList.UserID != viewerID {
+		respondWithError(w, http.StatusForbidden, "you can only change your own lists", nil)
+		return database.UserList{}, false
+	}
+
+	return dbList, true
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rickNoise/chirpy/internal/chirptext"
+)
+
+// POST /api/chirps/validate checks a chirp body against the same rules as POST /api/chirps without creating anything,
+// so clients can show an accurate character counter. It doesn't require authentication.
+//
+//	{"body": "hello 👋 https://example.com"}
+//
+// It responds with a 200 status code and whether the body is valid, its length (graphemes, with every link counting as 23),
+// the maximum length, the characters remaining (negative when over the limit), and the reason an invalid body would be rejected.
+func (cfg *ApiConfig) HandleValidateChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "error decoding req json body", err)
+		return
+	}
+
+	type response struct {
+		Valid     bool   `json:"valid"`
+		Length    int    `json:"length"`
+		MaxLength int    `json:"max_length"`
+		Remaining int    `json:"remaining"`
+		Error     string `json:"error,omitempty"`
+	}
+
+	body := chirptext.Normalize(params.Body)
+	length := chirptext.Length(body)
+	resp := response{
+		Valid:     true,
+		Length:    length,
+		MaxLength: maxChirpLength,
+		Remaining: maxChirpLength - length,
+	}
+	if err := validateChirpBody(body); err != nil {
+		resp.Valid = false
+		resp.Error = err.Error()
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/rickNoise/chirpy/internal/database"
+)
+
+// POST /api/chirps/{chirpID}/vote votes in the poll attached to a chirp, given the index of the chosen option as {"option": 1}.
+// Each user can vote once per poll, and votes can't be changed. It responds with a 200 status code and the poll, now including its results,
+// 404 if the chirp doesn't exist, isn't visible to the user or has no poll, and 409 if the poll has closed or the user has already voted.
+func (cfg *ApiConfig) HandleVoteInPoll(w http.ResponseWriter, r *http.Request) {
+	userID, dbChirp, ok := cfg.parseVisibleChirpRequest(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	type parameters struct {
+		Option *int `json:"option"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "error decoding req json body", err)
+		return
+	}
+
+	polls, err := cfg.loadChirpPolls(r.Context(), userID, []database.Chirp{dbChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get poll", err)
+		return
+	}
+	poll, found := polls[dbChirp.ID]
+	if !found {
+		respondWithError(w, http.StatusNotFound, "chirp has no poll", nil)
+		return
+	}
+	if poll.Closed {
+		respondWithError(w, http.StatusConflict, "poll is closed", nil)
+		return
+	}
+	if poll.VotedOption != nil {
+		respondWithError(w, http.StatusConflict, "you have already voted in this poll", nil)
+		return
+	}
+	if params.Option == nil || *params.Option < 0 || *params.Option >= len(poll.Options) {
+		respondWithError(w, http.StatusBadRequest, "option must be the index of one of the poll's options", nil)
+		return
+	}
+
+	rows, err := cfg.DbQueries.CreatePollVote(r.Context(), database.CreatePollVoteParams{
+		ChirpID:  dbChirp.ID,
+		UserID:   userID,
+		Position: int32(*params.Option),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not record vote", err)
+		return
+	}
+	// the poll may have closed, or a concurrent request may have recorded a vote, since the poll was loaded
+	if rows == 0 {
+		if !time.Now().Before(poll.ClosesAt) {
+			respondWithError(w, http.StatusConflict, "poll is closed", nil)
+		} else {
+			respondWithError(w, http.StatusConflict, "you have already voted in this poll", nil)
+		}
+		return
+	}
+
+	polls, err = cfg.loadChirpPolls(r.Context(), userID, []database.Chirp{dbChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get poll", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, polls[dbChirp.ID])
+}
@@ -0,0 +1,258 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/logging"
+	"github.com/rickNoise/chirpy/internal/stream"
+)
+
+/* CONSTANTS */
+// how often the server pings an idle client, and how long it waits for the pong
+const wsPingInterval = 30 * time.Second
+const wsPingTimeout = 10 * time.Second
+
+// how long a single message may take to write before the client is considered too slow
+const wsWriteTimeout = 10 * time.Second
+
+// largest message accepted from a client; client messages are small subscription commands
+const wsReadLimit = 4096
+
+// WebSocket channels a client can subscribe to.
+const (
+	wsChannelTimeline      = "timeline"      // every new and deleted chirp
+	wsChannelAuthor        = "author"        // chirps by a specific author
+	wsChannelNotifications = "notifications" // chirps mentioning the authenticated user
+)
+
+// A message sent by the client, e.g.
+//
+//	{"type": "subscribe", "channel": "author", "author_id": "3311741c-680c-4546-99f3-fc9efac2036c"}
+type wsClientMessage struct {
+	Type     string    `json:"type"` // "subscribe" or "unsubscribe"
+	Channel  string    `json:"channel"`
+	AuthorID uuid.UUID `json:"author_id"` // only used with the "author" channel
+}
+
+// A message sent by the server.
+type wsServerMessage struct {
+	Type     string          `json:"type"` // "event", "subscribed", "unsubscribed" or "error"
+	Channel  string          `json:"channel,omitempty"`
+	AuthorID *uuid.UUID      `json:"author_id,omitempty"`
+	Event    string          `json:"event,omitempty"`
+	ID       uint64          `json:"id,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// wsSubscriptions tracks which channels a single connection is subscribed to.
+// It is read by the hub while publishing, so it is guarded by its own lock rather than the connection's goroutines.
+type wsSubscriptions struct {
+	mu            sync.RWMutex
+	userID        uuid.UUID
+	viewer        *liveViewer // reloaded whenever the viewer's relationships change
+	timeline      bool
+	notifications bool
+	authors       map[uuid.UUID]struct{}
+}
+
+// match returns the channel an event should be delivered on, if any.
+func (s *wsSubscriptions) match(e stream.Event) (channel string, ok bool) {
+	if !stream.IsChirpEvent(e) {
+		return "", false
+	}
+	audience := chirpEventAudience(e)
+	if !s.viewer.canSee(audience, false) {
+		return "", false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.notifications && e.Type == stream.ChirpCreated && slices.Contains(e.MentionedUserIDs, s.userID) {
+		return wsChannelNotifications, true
+	}
+	if _, found := s.authors[e.AuthorID]; found {
+		return wsChannelAuthor, true
+	}
+	if s.timeline && s.viewer.canSee(audience, true) {
+		return wsChannelTimeline, true
+	}
+	return "", false
+}
+
+// apply updates the subscriptions from a client message, returning an error message for the client if it is invalid.
+func (s *wsSubscriptions) apply(msg wsClientMessage) string {
+	if msg.Type != "subscribe" && msg.Type != "unsubscribe" {
+		return "unknown message type"
+	}
+	subscribe := msg.Type == "subscribe"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch msg.Channel {
+	case wsChannelTimeline:
+		s.timeline = subscribe
+	case wsChannelNotifications:
+		s.notifications = subscribe
+	case wsChannelAuthor:
+		if msg.AuthorID == uuid.Nil {
+			return "author_id is required for the author channel"
+		}
+		if subscribe {
+			s.authors[msg.AuthorID] = struct{}{}
+		} else {
+			delete(s.authors, msg.AuthorID)
+		}
+	default:
+		return "unknown channel"
+	}
+	return ""
+}
+
+// GET /api/ws upgrades the connection to a WebSocket delivering live chirp events.
+//
+// The connection is authenticated with the same JWT access token as the rest of the API, sent either as a Bearer token or in an access_token query parameter.
+// Once connected, the client sends subscribe/unsubscribe messages for the "timeline", "author" (with an author_id) and "notifications" channels, and receives "event" messages for matching chirp.created, chirp.deleted and chirp.restored events.
+//
+// Events for chirps the client isn't allowed to see (see GET /api/chirps/{chirpID}) are never delivered, and the timeline channel also leaves out muted users and unlisted chirps;
+// the client's blocks, mutes and follows are reloaded whenever they change. The connection is closed with status 1008 (policy violation) if the client's account is deleted.
+//
+// The server pings the client every 30 seconds. Clients that cannot keep up are disconnected with status 1013 (try again later), and all clients are disconnected with status 1001 (going away) when the server shuts down.
+func (cfg *ApiConfig) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if cfg.Stream == nil {
+		respondWithError(w, http.StatusInternalServerError, "streaming unsupported", nil)
+		return
+	}
+
+	userID, ok := cfg.authenticateWebSocketUser(w, r)
+	if !ok {
+		return // helper already wrote the error response
+	}
+
+	viewer, err := cfg.loadChirpViewer(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not load blocks, mutes and follows", err)
+		return
+	}
+
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return // Accept already wrote the error response
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(wsReadLimit)
+
+	subs := &wsSubscriptions{
+		userID:  userID,
+		viewer:  newLiveViewer(viewer),
+		authors: make(map[uuid.UUID]struct{}),
+	}
+	sub, _ := cfg.Stream.Subscribe(func(e stream.Event) bool {
+		_, ok := subs.match(e)
+		return ok || subs.viewer.isViewerEvent(e)
+	}, 0)
+	defer sub.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// read subscription commands until the client goes away
+	go func() {
+		defer cancel()
+		for {
+			_, data, err := conn.Read(ctx)
+			if err != nil {
+				return
+			}
+			var msg wsClientMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				if err := writeWebSocketMessage(ctx, conn, wsServerMessage{Type: "error", Error: "invalid json message"}); err != nil {
+					return
+				}
+				continue
+			}
+
+			reply := wsServerMessage{Type: msg.Type + "d", Channel: msg.Channel}
+			if msg.Channel == wsChannelAuthor {
+				reply.AuthorID = &msg.AuthorID
+			}
+			if errMsg := subs.apply(msg); errMsg != "" {
+				reply = wsServerMessage{Type: "error", Error: errMsg}
+			}
+			if err := writeWebSocketMessage(ctx, conn, reply); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			pingCtx, cancelPing := context.WithTimeout(ctx, wsPingTimeout)
+			err := conn.Ping(pingCtx)
+			cancelPing()
+			if err != nil {
+				return
+			}
+		case e, open := <-sub.Events():
+			if !open {
+				select {
+				case <-cfg.Stream.Done():
+					conn.Close(websocket.StatusGoingAway, "server shutting down")
+				default:
+					conn.Close(websocket.StatusTryAgainLater, "client fell too far behind")
+				}
+				return
+			}
+			if subs.viewer.isViewerEvent(e) {
+				active, err := cfg.reloadLiveViewer(ctx, subs.viewer)
+				if err != nil {
+					logging.FromContext(r.Context()).Error("could not reload websocket viewer", "error", err)
+					conn.Close(websocket.StatusInternalError, "could not reload blocks, mutes and follows")
+					return
+				}
+				if !active {
+					conn.Close(websocket.StatusPolicyViolation, "account has been deleted")
+					return
+				}
+				continue
+			}
+			channel, ok := subs.match(e)
+			if !ok {
+				continue // unsubscribed, or the viewer's relationships changed, since the event was queued
+			}
+			err := writeWebSocketMessage(ctx, conn, wsServerMessage{
+				Type:    "event",
+				Channel: channel,
+				Event:   e.Type,
+				ID:      e.ID,
+				Data:    e.Data,
+			})
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeWebSocketMessage writes a single JSON message, giving up after wsWriteTimeout.
+func writeWebSocketMessage(ctx context.Context, conn *websocket.Conn, msg wsServerMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, wsWriteTimeout)
+	defer cancel()
+	return wsjson.Write(ctx, conn, msg)
+}
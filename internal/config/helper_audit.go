@@ -0,0 +1,126 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/logging"
+)
+
+// audit event types, as stored in audit_events.event_type
+const (
+	auditLogin          = "auth.login"
+	auditRefresh        = "auth.refresh"
+	auditRevoke         = "auth.revoke"
+	auditPasswordChange = "user.password_changed"
+	auditEmailChange    = "user.email_changed"
+	auditUpgrade        = "user.upgraded"
+	auditChirpDelete    = "chirp.deleted"
+	auditAdminReset     = "admin.reset"
+)
+
+// audit event outcomes, as stored in audit_events.outcome
+const (
+	auditSuccess = "success"
+	auditFailure = "failure"
+)
+
+// An auditEvent is a security-sensitive action to record in the audit log.
+// Audit events can't be deleted, so they must not hold personal data such as email addresses, which has to go when an account is purged;
+// users are identified by id, and unknown email addresses by auditEmailHash.
+type auditEvent struct {
+	eventType string
+	failed    bool
+	actorID   uuid.UUID         // the user who acted; uuid.Nil if unknown
+	targetID  uuid.UUID         // the user or chirp acted on; uuid.Nil if none
+	details   map[string]string // optional event-specific fields
+}
+
+// recordAuditEvent appends an event to the audit log, along with the client's IP address, user agent and request id.
+// The audit log is best effort: if the event can't be stored it is logged, and the request carries on.
+func (cfg *ApiConfig) recordAuditEvent(r *http.Request, e auditEvent) {
+	outcome := auditSuccess
+	if e.failed {
+		outcome = auditFailure
+	}
+	details := []byte("{}")
+	if len(e.details) > 0 {
+		var err error
+		if details, err = json.Marshal(e.details); err != nil {
+			logging.FromContext(r.Context()).Error("could not marshal audit event details", "event_type", e.eventType, "error", err)
+			details = []byte("{}")
+		}
+	}
+
+	err := cfg.DbQueries.CreateAuditEvent(r.Context(), database.CreateAuditEventParams{
+		EventType: e.eventType,
+		Outcome:   outcome,
+		ActorID:   uuid.NullUUID{UUID: e.actorID, Valid: e.actorID != uuid.Nil},
+		TargetID:  uuid.NullUUID{UUID: e.targetID, Valid: e.targetID != uuid.Nil},
+		IpAddress: cfg.clientIP(r),
+		UserAgent: r.UserAgent(),
+		RequestID: logging.RequestID(r.Context()),
+		Details:   details,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("could not record audit event", "event_type", e.eventType, "error", err)
+	}
+}
+
+// auditEmailHash identifies an email address in the audit log without storing it. The keyed hash lets repeated attempts with the same address
+// be correlated, but can't be reversed or checked against a guessed address without the key.
+// Without an AuditHashKey nothing identifies the address, so ok is false.
+func (cfg *ApiConfig) auditEmailHash(email string) (hash string, ok bool) {
+	if cfg.AuditHashKey == "" {
+		return "", false
+	}
+	mac := hmac.New(sha256.New, []byte(cfg.AuditHashKey))
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(mac.Sum(nil)[:16]), true
+}
+
+// clientIP returns the IP address of the client that sent a request, without its port.
+// When the request comes from one of the TrustedProxies, the address is taken from X-Forwarded-For instead: the nearest hop that isn't a trusted proxy,
+// since the hops further left were supplied by the client and could be made up.
+func (cfg *ApiConfig) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !cfg.isTrustedProxy(addr) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break // nothing from a malformed hop onwards can be trusted
+		}
+		addr = hop
+		if !cfg.isTrustedProxy(addr) {
+			break
+		}
+	}
+	return addr.Unmap().String()
+}
+
+// isTrustedProxy reports whether an address belongs to one of the TrustedProxies.
+func (cfg *ApiConfig) isTrustedProxy(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range cfg.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
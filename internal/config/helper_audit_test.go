@@ -0,0 +1,40 @@
+package config
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	cfg := &ApiConfig{TrustedProxies: []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}}
+
+	cases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		expected     string
+	}{
+		{name: "direct client", remoteAddr: "203.0.113.7:5000", expected: "203.0.113.7"},
+		{name: "untrusted client can't spoof its address", remoteAddr: "203.0.113.7:5000", forwardedFor: []string{"198.51.100.1"}, expected: "203.0.113.7"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.2:5000", forwardedFor: []string{"198.51.100.1"}, expected: "198.51.100.1"},
+		{name: "trusted proxy without the header", remoteAddr: "10.0.0.2:5000", expected: "10.0.0.2"},
+		{name: "client-supplied hops are ignored", remoteAddr: "10.0.0.2:5000", forwardedFor: []string{"192.0.2.99, 198.51.100.1"}, expected: "198.51.100.1"},
+		{name: "chain of trusted proxies", remoteAddr: "10.0.0.2:5000", forwardedFor: []string{"198.51.100.1, 10.1.1.1", "10.2.2.2"}, expected: "198.51.100.1"},
+		{name: "malformed hop", remoteAddr: "10.0.0.2:5000", forwardedFor: []string{"198.51.100.1, not-an-ip, 10.1.1.1"}, expected: "10.1.1.1"},
+		{name: "ipv6 proxy", remoteAddr: "[2001:db8::1]:5000", forwardedFor: []string{"2001:db8:ffff::1, 198.51.100.1"}, expected: "198.51.100.1"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = c.remoteAddr
+		for _, value := range c.forwardedFor {
+			r.Header.Add("X-Forwarded-For", value)
+		}
+		if got := cfg.clientIP(r); got != c.expected {
+			t.Errorf("%s: got %s, expected %s", c.name, got, c.expected)
+		}
+	}
+}
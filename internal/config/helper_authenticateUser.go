@@ -1,10 +1,14 @@
 package config
 
 import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/rickNoise/chirpy/internal/auth"
+	"github.com/rickNoise/chirpy/internal/logging"
 )
 
 // continue with update logic using userID
@@ -26,5 +30,71 @@ func (cfg *ApiConfig) authenticateUser(w http.ResponseWriter, r *http.Request) (
 		return uuid.Nil, false
 	}
 
+	if !cfg.checkActiveUser(w, r, userID) {
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// checkActiveUser rejects the access token of a user who has deleted their account (or been purged), since it stays valid until it expires.
+// Otherwise the rest of the request's log lines, and its access log, name the user.
+func (cfg *ApiConfig) checkActiveUser(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (ok bool) {
+	deletedAt, err := cfg.DbQueries.GetUserDeletedAt(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) || deletedAt.Valid {
+		respondWithError(w, http.StatusUnauthorized, "user account has been deleted", err)
+		return false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not look up user", err)
+		return false
+	}
+
+	logging.SetUserID(r.Context(), userID.String())
+	return true
+}
+
+// authenticateWebSocketUser behaves like authenticateUser, but also accepts the access token in an "access_token" query parameter.
+// Browsers cannot set an Authorization header when opening a WebSocket, so the token has to travel in the URL instead.
+func (cfg *ApiConfig) authenticateWebSocketUser(w http.ResponseWriter, r *http.Request) (userID uuid.UUID, ok bool) {
+	queryToken := r.URL.Query().Get("access_token")
+	if queryToken == "" || r.Header.Get("Authorization") != "" {
+		return cfg.authenticateUser(w, r)
+	}
+
+	userID, err := auth.ValidateJWT(queryToken, cfg.JWTSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "access token could not be validated", err)
+		return uuid.Nil, false
+	}
+
+	if !cfg.checkActiveUser(w, r, userID) {
+		return uuid.Nil, false
+	}
 	return userID, true
 }
+
+// authenticateOptionalUser is used by public endpoints whose results depend on who is asking.
+// Requests without an Authorization header are anonymous and return uuid.Nil; a header with an invalid token is still rejected.
+func (cfg *ApiConfig) authenticateOptionalUser(w http.ResponseWriter, r *http.Request) (userID uuid.UUID, ok bool) {
+	if r.Header.Get("Authorization") == "" {
+		return uuid.Nil, true
+	}
+	return cfg.authenticateUser(w, r)
+}
+
+// authenticateAdmin checks that a request to an admin endpoint carries the ADMIN_KEY in an "Authorization: ApiKey <key>" header.
+// Admin endpoints are disabled entirely when no admin key is configured.
+func (cfg *ApiConfig) authenticateAdmin(w http.ResponseWriter, r *http.Request) (ok bool) {
+	if cfg.AdminKey == "" {
+		respondWithError(w, http.StatusForbidden, "admin endpoints are disabled", nil)
+		return false
+	}
+
+	headerKey, err := auth.GetAPIKey(r.Header)
+	if err != nil || subtle.ConstantTimeCompare([]byte(headerKey), []byte(cfg.AdminKey)) != 1 {
+		respondWithError(w, http.StatusUnauthorized, "invalid admin key", err)
+		return false
+	}
+
+	return true
+}
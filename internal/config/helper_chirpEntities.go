@@ -0,0 +1,108 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/chirptext"
+	"github.com/rickNoise/chirpy/internal/database"
+)
+
+// storeChirpEntities parses the hashtags, mentions and previewed link out of a (censored) chirp body and records them in the join tables.
+// Mentions of handles that do not belong to any user are dropped, as are mentions of users the author has blocked or been blocked by, so a blocked user can never notify the blocker.
+// q should be transaction-scoped so that a chirp is never stored without its entities.
+func storeChirpEntities(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	hashtags, mentions := chirptext.ParseEntities(chirp.Body)
+
+	for _, h := range hashtags {
+		err := q.CreateChirpHashtag(ctx, database.CreateChirpHashtagParams{
+			ChirpID:     chirp.ID,
+			Tag:         h.Tag,
+			StartOffset: int32(h.Start),
+			EndOffset:   int32(h.End),
+		})
+		if err != nil {
+			return fmt.Errorf("could not store hashtag %q: %w", h.Tag, err)
+		}
+	}
+
+	if err := storeChirpLinkPreview(ctx, q, chirp); err != nil {
+		return err
+	}
+
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	// resolve all mentioned handles to user ids in a single query
+	lowerHandles := make([]string, 0, len(mentions))
+	for _, m := range mentions {
+		lowerHandles = append(lowerHandles, strings.ToLower(m.Handle))
+	}
+	dbUsers, err := q.GetUsersByHandles(ctx, lowerHandles)
+	if err != nil {
+		return fmt.Errorf("could not resolve mentioned handles: %w", err)
+	}
+	userIDsByHandle := make(map[string]uuid.UUID)
+	for _, u := range dbUsers {
+		userIDsByHandle[strings.ToLower(u.Handle.String)] = u.ID
+	}
+
+	blockedUserIDs, err := q.GetBlockRelatedUserIDs(ctx, chirp.UserID)
+	if err != nil {
+		return fmt.Errorf("could not load blocks: %w", err)
+	}
+
+	for _, m := range mentions {
+		userID, found := userIDsByHandle[strings.ToLower(m.Handle)]
+		if !found || slices.Contains(blockedUserIDs, userID) {
+			continue
+		}
+		err := q.CreateChirpMention(ctx, database.CreateChirpMentionParams{
+			ChirpID:     chirp.ID,
+			UserID:      userID,
+			Handle:      m.Handle,
+			StartOffset: int32(m.Start),
+			EndOffset:   int32(m.End),
+		})
+		if err != nil {
+			return fmt.Errorf("could not store mention of %q: %w", m.Handle, err)
+		}
+	}
+
+	return nil
+}
+
+// loadChirpEntities fetches the stored hashtags and mentions for a batch of chirps, keyed by chirp id.
+// Chirps without any entities are absent from the returned map.
+func (cfg *ApiConfig) loadChirpEntities(ctx context.Context, chirpIDs []uuid.UUID) (map[uuid.UUID]ChirpEntities, error) {
+	entities := make(map[uuid.UUID]ChirpEntities)
+	if len(chirpIDs) == 0 {
+		return entities, nil
+	}
+
+	dbHashtags, err := cfg.DbQueries.GetHashtagsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, fmt.Errorf("could not load hashtags: %w", err)
+	}
+	for _, h := range dbHashtags {
+		e := entities[h.ChirpID]
+		e.Hashtags = append(e.Hashtags, DatabaseHashtagToAPIHashtag(h))
+		entities[h.ChirpID] = e
+	}
+
+	dbMentions, err := cfg.DbQueries.GetMentionsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, fmt.Errorf("could not load mentions: %w", err)
+	}
+	for _, m := range dbMentions {
+		e := entities[m.ChirpID]
+		e.Mentions = append(e.Mentions, DatabaseMentionToAPIMention(m))
+		entities[m.ChirpID] = e
+	}
+
+	return entities, nil
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,6 +28,11 @@ type User struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 	Email       string    `json:"email"`
 	IsChirpyRed bool      `json:"is_chirpy_red"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+	IsPrivate   bool      `json:"is_private"`
 }
 
 // Returns a user struct appropriate for public API responses (e.g. no hashed password included) (including json struct tags)
@@ -37,26 +43,564 @@ func DatabaseUserToAPIUser(u database.User) User {
 		UpdatedAt:   u.UpdatedAt,
 		Email:       u.Email,
 		IsChirpyRed: u.IsChirpyRed,
+		Handle:      u.Handle.String,
+		DisplayName: u.DisplayName,
+		Bio:         u.Bio,
+		AvatarURL:   avatarURL(u.AvatarMediaID),
+		IsPrivate:   u.IsPrivate,
 	}
 }
 
+// A user's public profile, as seen by anyone. Unlike User, the email address is excluded.
+type Profile struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+	IsPrivate   bool      `json:"is_private"`
+}
+
+func DatabaseUserToAPIProfile(u database.User) Profile {
+	return Profile{
+		ID:          u.ID,
+		CreatedAt:   u.CreatedAt,
+		Handle:      u.Handle.String,
+		DisplayName: u.DisplayName,
+		Bio:         u.Bio,
+		AvatarURL:   avatarURL(u.AvatarMediaID),
+		IsChirpyRed: u.IsChirpyRed,
+		IsPrivate:   u.IsPrivate,
+	}
+}
+
+// The minimal author details embedded in chirps.
+type UserSummary struct {
+	ID          uuid.UUID `json:"id"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+}
+
+func DatabaseUserSummaryToAPIUserSummary(u database.GetUserSummariesRow) UserSummary {
+	return UserSummary{
+		ID:          u.ID,
+		Handle:      u.Handle.String,
+		DisplayName: u.DisplayName,
+		AvatarURL:   avatarURL(u.AvatarMediaID),
+	}
+}
+
+// avatars are served at the small thumbnail size
+func avatarURL(mediaID uuid.NullUUID) string {
+	if !mediaID.Valid {
+		return ""
+	}
+	return "/api/media/" + mediaID.UUID.String() + "/thumbnails/small"
+}
+
+// A user the requesting user has a relationship with (e.g. has blocked or muted), and since when.
+type UserRelationship struct {
+	User      UserSummary `json:"user"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type userRelationship struct {
+	userID    uuid.UUID
+	createdAt time.Time
+}
+
+// userRelationshipsToAPI loads the summaries of all related users in a single query, keeping their order.
+func (cfg *ApiConfig) userRelationshipsToAPI(ctx context.Context, relationships []userRelationship) ([]UserRelationship, error) {
+	userIDs := make([]uuid.UUID, 0, len(relationships))
+	for _, rel := range relationships {
+		userIDs = append(userIDs, rel.userID)
+	}
+	dbSummaries, err := cfg.DbQueries.GetUserSummaries(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make(map[uuid.UUID]UserSummary, len(dbSummaries))
+	for _, u := range dbSummaries {
+		summaries[u.ID] = DatabaseUserSummaryToAPIUserSummary(u)
+	}
+
+	jsonRelationships := make([]UserRelationship, 0, len(relationships))
+	for _, rel := range relationships {
+		summary, found := summaries[rel.userID]
+		if !found {
+			summary = UserSummary{ID: rel.userID}
+		}
+		jsonRelationships = append(jsonRelationships, UserRelationship{User: summary, CreatedAt: rel.createdAt})
+	}
+	return jsonRelationships, nil
+}
+
 /* CHIRPS */
 
 type Chirp struct {
-	Id        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserId    uuid.UUID `json:"user_id"`
+	Id          uuid.UUID     `json:"id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	Body        string        `json:"body"`
+	UserId      uuid.UUID     `json:"user_id"`
+	Visibility  string        `json:"visibility"`
+	PublishAt   *time.Time    `json:"publish_at,omitempty"` // only set while the chirp is scheduled
+	DeletedAt   *time.Time    `json:"deleted_at,omitempty"` // only set on deleted chirps that haven't been purged yet
+	Author      UserSummary   `json:"author"`
+	Entities    ChirpEntities `json:"entities"`
+	Media       []Media       `json:"media"`
+	Poll        *Poll         `json:"poll,omitempty"`
+	LinkPreview *LinkPreview  `json:"link_preview,omitempty"` // the card for the first link in the body, once it has been fetched
 }
 
-// Returns a chirp struct appropriate for public API responses (including json struct tags)
+// Returns a chirp struct appropriate for public API responses (including json struct tags).
+// Entities, media and author details are left empty; use databaseChirpsToAPIChirps to include them.
 func DatabaseChirpToAPIChirp(c database.Chirp) Chirp {
-	return Chirp{
-		Id:        c.ID,
-		CreatedAt: c.CreatedAt,
-		UpdatedAt: c.UpdatedAt,
-		Body:      c.Body,
-		UserId:    c.UserID,
+	chirp := Chirp{
+		Id:         c.ID,
+		CreatedAt:  c.CreatedAt,
+		UpdatedAt:  c.UpdatedAt,
+		Body:       c.Body,
+		UserId:     c.UserID,
+		Visibility: c.Visibility,
+		Author:     UserSummary{ID: c.UserID},
+		Entities: ChirpEntities{
+			Hashtags: []Hashtag{},
+			Mentions: []Mention{},
+		},
+		Media: []Media{},
+	}
+	if c.PublishAt.Valid {
+		chirp.PublishAt = &c.PublishAt.Time
+	}
+	if c.DeletedAt.Valid {
+		chirp.DeletedAt = &c.DeletedAt.Time
+	}
+	return chirp
+}
+
+// Converts a batch of db chirps into API chirps, loading the authors, entities, media, polls and link previews for all of them with one query per type.
+// Poll results depend on the viewer (uuid.Nil for anonymous viewers and broadcasts); see loadChirpPolls.
+func (cfg *ApiConfig) databaseChirpsToAPIChirps(ctx context.Context, viewerID uuid.UUID, dbChirps []database.Chirp) ([]Chirp, error) {
+	chirpIDs := make([]uuid.UUID, 0, len(dbChirps))
+	authorIDs := make([]uuid.UUID, 0, len(dbChirps))
+	for _, c := range dbChirps {
+		chirpIDs = append(chirpIDs, c.ID)
+		authorIDs = append(authorIDs, c.UserID)
+	}
+
+	dbAuthors, err := cfg.DbQueries.GetUserSummaries(ctx, authorIDs)
+	if err != nil {
+		return nil, err
+	}
+	authors := make(map[uuid.UUID]UserSummary, len(dbAuthors))
+	for _, a := range dbAuthors {
+		authors[a.ID] = DatabaseUserSummaryToAPIUserSummary(a)
+	}
+
+	entities, err := cfg.loadChirpEntities(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	dbMedia, err := cfg.DbQueries.GetMediaForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	jsonMedia, err := cfg.databaseMediaToAPIMedia(ctx, dbMedia)
+	if err != nil {
+		return nil, err
+	}
+	mediaByChirp := make(map[uuid.UUID][]Media)
+	for i, m := range dbMedia {
+		mediaByChirp[m.ChirpID.UUID] = append(mediaByChirp[m.ChirpID.UUID], jsonMedia[i])
+	}
+
+	polls, err := cfg.loadChirpPolls(ctx, viewerID, dbChirps)
+	if err != nil {
+		return nil, err
+	}
+
+	linkPreviews, err := cfg.loadChirpLinkPreviews(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var jsonChirps []Chirp
+	for _, dbChirp := range dbChirps {
+		jsonChirp := DatabaseChirpToAPIChirp(dbChirp)
+		if a, found := authors[dbChirp.UserID]; found {
+			jsonChirp.Author = a
+		}
+		if e, found := entities[dbChirp.ID]; found {
+			if e.Hashtags != nil {
+				jsonChirp.Entities.Hashtags = e.Hashtags
+			}
+			if e.Mentions != nil {
+				jsonChirp.Entities.Mentions = e.Mentions
+			}
+		}
+		if m, found := mediaByChirp[dbChirp.ID]; found {
+			jsonChirp.Media = m
+		}
+		jsonChirp.Poll = polls[dbChirp.ID]
+		jsonChirp.LinkPreview = linkPreviews[dbChirp.ID]
+		jsonChirps = append(jsonChirps, jsonChirp)
+	}
+
+	return jsonChirps, nil
+}
+
+/* POLLS */
+
+// A poll attached to a chirp. Vote counts are left out until the viewer has voted or the poll has closed.
+type Poll struct {
+	Options        []PollOption `json:"options"`
+	ClosesAt       time.Time    `json:"closes_at"`
+	Closed         bool         `json:"closed"`
+	VotedOption    *int32       `json:"voted_option,omitempty"` // the index of the option the viewer voted for
+	ResultsVisible bool         `json:"results_visible"`
+	TotalVotes     *int64       `json:"total_votes,omitempty"`
+}
+
+type PollOption struct {
+	Text  string `json:"text"`
+	Votes *int64 `json:"votes,omitempty"`
+}
+
+/* LINK PREVIEWS */
+
+// The preview card of a linked page. url is the page's canonical url, which may differ from the link in the chirp.
+type LinkPreview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	ImageURL    string `json:"image_url,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+func DatabaseLinkPreviewToAPILinkPreview(p database.LinkPreview) LinkPreview {
+	return LinkPreview{
+		URL:         p.CanonicalUrl,
+		Title:       p.Title,
+		Description: p.Description,
+		ImageURL:    p.ImageUrl,
+		SiteName:    p.SiteName,
+	}
+}
+
+/* CHIRP ENTITIES */
+
+// Structured hashtags and mentions parsed from a chirp body.
+// Offsets are rune (Unicode code point) offsets into the body; start is inclusive and end is exclusive.
+type ChirpEntities struct {
+	Hashtags []Hashtag `json:"hashtags"`
+	Mentions []Mention `json:"mentions"`
+}
+
+type Hashtag struct {
+	Tag   string `json:"tag"`
+	Start int32  `json:"start"`
+	End   int32  `json:"end"`
+}
+
+type Mention struct {
+	UserId uuid.UUID `json:"user_id"`
+	Handle string    `json:"handle"`
+	Start  int32     `json:"start"`
+	End    int32     `json:"end"`
+}
+
+func DatabaseHashtagToAPIHashtag(h database.ChirpHashtag) Hashtag {
+	return Hashtag{
+		Tag:   h.Tag,
+		Start: h.StartOffset,
+		End:   h.EndOffset,
+	}
+}
+
+func DatabaseMentionToAPIMention(m database.ChirpMention) Mention {
+	return Mention{
+		UserId: m.UserID,
+		Handle: m.Handle,
+		Start:  m.StartOffset,
+		End:    m.EndOffset,
+	}
+}
+
+/* MEDIA */
+
+// A media struct for public API responses. The storage key is excluded; clients fetch the bytes from url.
+// Uploads are processed in the background: until status is "ready", url and the thumbnails are not available.
+type Media struct {
+	ID          uuid.UUID        `json:"id"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UserId      uuid.UUID        `json:"user_id"`
+	URL         string           `json:"url"`
+	ContentType string           `json:"content_type"`
+	SizeBytes   int64            `json:"size_bytes"`
+	Width       int32            `json:"width"`
+	Height      int32            `json:"height"`
+	Status      string           `json:"status"` // "pending", "processing", "ready" or "failed"
+	Blurhash    string           `json:"blurhash,omitempty"`
+	Thumbnails  []MediaThumbnail `json:"thumbnails"`
+}
+
+type MediaThumbnail struct {
+	Size        string `json:"size"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int32  `json:"width"`
+	Height      int32  `json:"height"`
+}
+
+func DatabaseMediaToAPIMedia(m database.Medium) Media {
+	return Media{
+		ID:          m.ID,
+		CreatedAt:   m.CreatedAt,
+		UserId:      m.UserID,
+		URL:         "/api/media/" + m.ID.String() + "/content",
+		ContentType: m.ContentType,
+		SizeBytes:   m.SizeBytes,
+		Width:       m.Width,
+		Height:      m.Height,
+		Status:      m.ProcessingStatus,
+		Blurhash:    m.Blurhash.String,
+		Thumbnails:  []MediaThumbnail{},
+	}
+}
+
+func DatabaseMediaThumbnailToAPIMediaThumbnail(t database.MediaThumbnail) MediaThumbnail {
+	return MediaThumbnail{
+		Size:        t.SizeName,
+		URL:         "/api/media/" + t.MediaID.String() + "/thumbnails/" + t.SizeName,
+		ContentType: t.ContentType,
+		Width:       t.Width,
+		Height:      t.Height,
+	}
+}
+
+// databaseMediaToAPIMedia converts media items and loads their thumbnails in a single query.
+func (cfg *ApiConfig) databaseMediaToAPIMedia(ctx context.Context, dbMedia []database.Medium) ([]Media, error) {
+	mediaIDs := make([]uuid.UUID, 0, len(dbMedia))
+	for _, m := range dbMedia {
+		mediaIDs = append(mediaIDs, m.ID)
+	}
+
+	dbThumbnails, err := cfg.DbQueries.GetThumbnailsForMedia(ctx, mediaIDs)
+	if err != nil {
+		return nil, err
+	}
+	thumbnailsByMedia := make(map[uuid.UUID][]MediaThumbnail)
+	for _, t := range dbThumbnails {
+		thumbnailsByMedia[t.MediaID] = append(thumbnailsByMedia[t.MediaID], DatabaseMediaThumbnailToAPIMediaThumbnail(t))
+	}
+
+	jsonMedia := make([]Media, 0, len(dbMedia))
+	for _, m := range dbMedia {
+		jsonM := DatabaseMediaToAPIMedia(m)
+		if t, found := thumbnailsByMedia[m.ID]; found {
+			jsonM.Thumbnails = t
+		}
+		jsonMedia = append(jsonMedia, jsonM)
+	}
+	return jsonMedia, nil
+}
+
+/* TRENDS */
+
+type Trend struct {
+	Tag        string    `json:"tag"`
+	Score      float64   `json:"score"`
+	Count      int32     `json:"count"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+func DatabaseTrendToAPITrend(t database.HashtagTrend) Trend {
+	return Trend{
+		Tag:        t.Tag,
+		Score:      t.Score,
+		Count:      t.CurrentCount,
+		ComputedAt: t.ComputedAt,
+	}
+}
+
+/* DRAFTS */
+
+type Draft struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Body       string    `json:"body"`
+	Visibility string    `json:"visibility"`
+}
+
+func DatabaseDraftToAPIDraft(d database.Draft) Draft {
+	return Draft{
+		ID:         d.ID,
+		CreatedAt:  d.CreatedAt,
+		UpdatedAt:  d.UpdatedAt,
+		Body:       d.Body,
+		Visibility: d.Visibility,
+	}
+}
+
+/* COLLECTIONS */
+
+type Collection struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	UserID      uuid.UUID `json:"user_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	IsPublic    bool      `json:"is_public"`
+}
+
+func DatabaseCollectionToAPICollection(c database.Collection) Collection {
+	return Collection{
+		ID:          c.ID,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+		UserID:      c.UserID,
+		Name:        c.Name,
+		Description: c.Description,
+		IsPublic:    c.IsPublic,
+	}
+}
+
+/* USER LISTS */
+
+type UserList struct {
+	ID              uuid.UUID `json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	UserID          uuid.UUID `json:"user_id"`
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	IsPublic        bool      `json:"is_public"`
+	MemberCount     int64     `json:"member_count"`
+	SubscriberCount int64     `json:"subscriber_count"`
+}
+
+// Returns a list struct appropriate for public API responses; counts are left empty, use userListsToAPI to include them.
+func DatabaseUserListToAPIUserList(l database.UserList) UserList {
+	return UserList{
+		ID:          l.ID,
+		CreatedAt:   l.CreatedAt,
+		UpdatedAt:   l.UpdatedAt,
+		UserID:      l.UserID,
+		Name:        l.Name,
+		Description: l.Description,
+		IsPublic:    l.IsPublic,
+	}
+}
+
+// Converts a batch of db lists into API lists, loading their member and subscriber counts with a single query.
+func (cfg *ApiConfig) userListsToAPI(ctx context.Context, dbLists []database.UserList) ([]UserList, error) {
+	listIDs := make([]uuid.UUID, 0, len(dbLists))
+	for _, l := range dbLists {
+		listIDs = append(listIDs, l.ID)
+	}
+	counts, err := cfg.DbQueries.GetUserListCounts(ctx, listIDs)
+	if err != nil {
+		return nil, err
+	}
+	countsByList := make(map[uuid.UUID]database.GetUserListCountsRow, len(counts))
+	for _, c := range counts {
+		countsByList[c.ID] = c
+	}
+
+	jsonLists := make([]UserList, 0, len(dbLists))
+	for _, l := range dbLists {
+		jsonList := DatabaseUserListToAPIUserList(l)
+		jsonList.MemberCount = countsByList[l.ID].MemberCount
+		jsonList.SubscriberCount = countsByList[l.ID].SubscriberCount
+		jsonLists = append(jsonLists, jsonList)
+	}
+	return jsonLists, nil
+}
+
+/* DIRECT MESSAGES */
+
+// A conversation as seen by one of its participants: other_user is the person they are talking to.
+type Conversation struct {
+	ID          uuid.UUID   `json:"id"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	OtherUser   UserSummary `json:"other_user"`
+	UnreadCount int64       `json:"unread_count"`
+}
+
+type DirectMessage struct {
+	ID             uuid.UUID  `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ConversationID uuid.UUID  `json:"conversation_id"`
+	SenderID       uuid.UUID  `json:"sender_id"`
+	Body           string     `json:"body"`
+	ReadAt         *time.Time `json:"read_at"` // null until the recipient has read the message
+}
+
+func DatabaseDirectMessageToAPIDirectMessage(m database.DirectMessage) DirectMessage {
+	message := DirectMessage{
+		ID:             m.ID,
+		CreatedAt:      m.CreatedAt,
+		ConversationID: m.ConversationID,
+		SenderID:       m.SenderID,
+		Body:           m.Body,
+	}
+	if m.ReadAt.Valid {
+		message.ReadAt = &m.ReadAt.Time
+	}
+	return message
+}
+
+// conversationsToAPI converts a batch of conversations as seen by the viewer, loading the other participants and unread counts with one query each.
+func (cfg *ApiConfig) conversationsToAPI(ctx context.Context, viewerID uuid.UUID, dbConversations []database.Conversation) ([]Conversation, error) {
+	conversationIDs := make([]uuid.UUID, 0, len(dbConversations))
+	otherUserIDs := make([]uuid.UUID, 0, len(dbConversations))
+	for _, c := range dbConversations {
+		conversationIDs = append(conversationIDs, c.ID)
+		otherUserIDs = append(otherUserIDs, conversationOtherUser(c, viewerID))
+	}
+
+	dbSummaries, err := cfg.DbQueries.GetUserSummaries(ctx, otherUserIDs)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make(map[uuid.UUID]UserSummary, len(dbSummaries))
+	for _, u := range dbSummaries {
+		summaries[u.ID] = DatabaseUserSummaryToAPIUserSummary(u)
+	}
+
+	dbCounts, err := cfg.DbQueries.GetUnreadMessageCounts(ctx, database.GetUnreadMessageCountsParams{
+		ConversationIds: conversationIDs,
+		UserID:          viewerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	unreadCounts := make(map[uuid.UUID]int64, len(dbCounts))
+	for _, c := range dbCounts {
+		unreadCounts[c.ConversationID] = c.UnreadCount
+	}
+
+	jsonConversations := make([]Conversation, 0, len(dbConversations))
+	for i, c := range dbConversations {
+		otherUser, found := summaries[otherUserIDs[i]]
+		if !found {
+			otherUser = UserSummary{ID: otherUserIDs[i]}
+		}
+		jsonConversations = append(jsonConversations, Conversation{
+			ID:          c.ID,
+			CreatedAt:   c.CreatedAt,
+			UpdatedAt:   c.UpdatedAt,
+			OtherUser:   otherUser,
+			UnreadCount: unreadCounts[c.ID],
+		})
 	}
+	return jsonConversations, nil
 }
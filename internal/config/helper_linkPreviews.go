@@ -0,0 +1,92 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/chirptext"
+	"github.com/rickNoise/chirpy/internal/database"
+)
+
+// cached previews (and failures) are fetched again when linked to after this long
+const linkPreviewRefreshAfter = 7 * 24 * time.Hour
+
+// longer links are shown as-is, without a preview card
+const maxLinkPreviewURLLength = 2048
+
+// chirpPreviewURL returns the link a chirp shows a preview card for: the first http(s) link in its body.
+func chirpPreviewURL(body string) (string, bool) {
+	for _, u := range chirptext.ParseURLs(body) {
+		if len(u.URL) > maxLinkPreviewURLLength {
+			continue
+		}
+		if _, err := url.Parse(u.URL); err != nil {
+			continue
+		}
+		return u.URL, true
+	}
+	return "", false
+}
+
+// storeChirpLinkPreview records the link a chirp shows a preview card for, queueing it for fetching unless a fresh preview is cached.
+// q should be transaction-scoped; call enqueueChirpLinkPreview once the transaction has committed.
+func storeChirpLinkPreview(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	previewURL, found := chirpPreviewURL(chirp.Body)
+	if !found {
+		return nil
+	}
+	err := q.RequestLinkPreview(ctx, database.RequestLinkPreviewParams{
+		Url:         previewURL,
+		StaleBefore: time.Now().UTC().Add(-linkPreviewRefreshAfter),
+	})
+	if err != nil {
+		return fmt.Errorf("could not request link preview: %w", err)
+	}
+	err = q.CreateChirpLinkPreview(ctx, database.CreateChirpLinkPreviewParams{
+		ChirpID: chirp.ID,
+		Url:     previewURL,
+	})
+	if err != nil {
+		return fmt.Errorf("could not store link preview: %w", err)
+	}
+	return nil
+}
+
+// loadChirpLinkPreviews fetches the ready preview cards for a batch of chirps, keyed by chirp id.
+// Chirps without a link, or whose link hasn't been fetched (or had no preview), are absent from the returned map.
+func (cfg *ApiConfig) loadChirpLinkPreviews(ctx context.Context, chirpIDs []uuid.UUID) (map[uuid.UUID]*LinkPreview, error) {
+	previews := make(map[uuid.UUID]*LinkPreview)
+	if len(chirpIDs) == 0 {
+		return previews, nil
+	}
+
+	dbChirpLinks, err := cfg.DbQueries.GetChirpLinkPreviews(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(dbChirpLinks) == 0 {
+		return previews, nil
+	}
+	urls := make([]string, 0, len(dbChirpLinks))
+	for _, l := range dbChirpLinks {
+		urls = append(urls, l.Url)
+	}
+	dbPreviews, err := cfg.DbQueries.GetReadyLinkPreviews(ctx, urls)
+	if err != nil {
+		return nil, err
+	}
+	byURL := make(map[string]LinkPreview, len(dbPreviews))
+	for _, p := range dbPreviews {
+		byURL[p.Url] = DatabaseLinkPreviewToAPILinkPreview(p)
+	}
+
+	for _, l := range dbChirpLinks {
+		if p, found := byURL[l.Url]; found {
+			previews[l.ChirpID] = &p
+		}
+	}
+	return previews, nil
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/stream"
+)
+
+// A liveViewer is the viewer of a long-lived stream (GET /api/stream or GET /api/ws).
+// Their blocks, mutes and follows can change while the stream is open, so the stream reloads them whenever a user event about the viewer arrives.
+// It is read by the hub while publishing, so the viewer is guarded by its own lock.
+type liveViewer struct {
+	userID uuid.UUID // uuid.Nil for anonymous viewers; never changes
+
+	mu     sync.RWMutex
+	viewer chirpViewer
+}
+
+func newLiveViewer(viewer chirpViewer) *liveViewer {
+	return &liveViewer{userID: viewer.userID, viewer: viewer}
+}
+
+// canSee applies chirpViewer.canSee with the viewer's current relationships.
+func (v *liveViewer) canSee(audience chirpAudience, feed bool) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.viewer.canSee(audience, feed)
+}
+
+// isViewerEvent reports whether an event means the viewer has to be reloaded: the viewer's account changed (it may have been deleted),
+// or one of their blocks, mutes or follows did. Streams subscribe to these events but never send them to the client.
+func (v *liveViewer) isViewerEvent(e stream.Event) bool {
+	if v.userID == uuid.Nil || e.AuthorID != v.userID {
+		return false
+	}
+	return e.Type == stream.UserUpdated || e.Type == stream.UserRelationshipsChanged
+}
+
+// reloadLiveViewer reloads the viewer's relationships. It returns false if the viewer's account has been deleted, in which case the stream should be closed.
+func (cfg *ApiConfig) reloadLiveViewer(ctx context.Context, v *liveViewer) (active bool, err error) {
+	deletedAt, err := cfg.DbQueries.GetUserDeletedAt(ctx, v.userID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && deletedAt.Valid) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	viewer, err := cfg.loadChirpViewer(ctx, v.userID)
+	if err != nil {
+		return false, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.viewer = viewer
+	return true, nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// default and maximum number of items in a page of results
+const defaultPageSize = 20
+const maxPageSize = 100
+
+// A pageCursor marks the last item of a page in a list ordered newest first by (time, id).
+// The next page holds the items that sort after it. Clients treat it as an opaque string.
+type pageCursor struct {
+	time time.Time
+	id   uuid.UUID
+}
+
+// firstPageCursor sorts before every item, so the first page starts at the newest item.
+var firstPageCursor = pageCursor{time: time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC), id: uuid.Max}
+
+func (c pageCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.time.Format(time.RFC3339Nano) + "|" + c.id.String()))
+}
+
+func parsePageCursor(s string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	rawTime, rawID, found := strings.Cut(string(raw), "|")
+	if !found {
+		return pageCursor{}, errors.New("malformed cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, rawTime)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	return pageCursor{time: t, id: id}, nil
+}
+
+// parsePageRequest reads the optional limit and cursor query parameters, writing an error response if they are invalid.
+func parsePageRequest(w http.ResponseWriter, r *http.Request) (limit int32, cursor pageCursor, ok bool) {
+	limit = defaultPageSize
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		parsed, err := strconv.Atoi(rawLimit)
+		if err != nil || parsed < 1 || parsed > maxPageSize {
+			respondWithError(w, http.StatusBadRequest, "limit must be a number between 1 and "+strconv.Itoa(maxPageSize), err)
+			return 0, pageCursor{}, false
+		}
+		limit = int32(parsed)
+	}
+
+	cursor = firstPageCursor
+	if rawCursor := r.URL.Query().Get("cursor"); rawCursor != "" {
+		parsed, err := parsePageCursor(rawCursor)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid cursor", err)
+			return 0, pageCursor{}, false
+		}
+		cursor = parsed
+	}
+
+	return limit, cursor, true
+}
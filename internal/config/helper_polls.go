@@ -0,0 +1,165 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+)
+
+// limits on the polls Chirpy Red users can attach to their chirps
+const minPollOptions = 2
+const maxPollOptions = 4
+const maxPollOptionLength = 25
+const minPollDuration = 5 * time.Minute
+const maxPollDuration = 7 * 24 * time.Hour
+
+// pollParameters is the optional "poll" object of a POST /api/chirps request body.
+type pollParameters struct {
+	Options         []string `json:"options"`
+	DurationMinutes int      `json:"duration_minutes"`
+}
+
+// validatePoll checks a requested poll, returning its options with surrounding whitespace trimmed.
+// The returned error message is suitable for showing to the client.
+func validatePoll(p pollParameters) ([]string, error) {
+	if len(p.Options) < minPollOptions || len(p.Options) > maxPollOptions {
+		return nil, fmt.Errorf("a poll must have between %d and %d options", minPollOptions, maxPollOptions)
+	}
+	options := make([]string, 0, len(p.Options))
+	for _, option := range p.Options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return nil, errors.New("poll options cannot be empty")
+		}
+		if utf8.RuneCountInString(option) > maxPollOptionLength {
+			return nil, fmt.Errorf("poll options can be at most %d characters", maxPollOptionLength)
+		}
+		for _, other := range options {
+			if strings.EqualFold(option, other) {
+				return nil, errors.New("poll options must be different from each other")
+			}
+		}
+		options = append(options, option)
+	}
+
+	duration := time.Duration(p.DurationMinutes) * time.Minute
+	if duration < minPollDuration || duration > maxPollDuration {
+		return nil, fmt.Errorf("poll duration_minutes must be between %d and %d", int(minPollDuration.Minutes()), int(maxPollDuration.Minutes()))
+	}
+
+	return options, nil
+}
+
+// storeChirpPoll attaches a validated poll to a chirp.
+// q should be transaction-scoped so that a chirp is never stored without its poll.
+func storeChirpPoll(ctx context.Context, q *database.Queries, chirpID uuid.UUID, options []string, durationMinutes int) error {
+	err := q.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:         chirpID,
+		DurationMinutes: int32(durationMinutes),
+	})
+	if err != nil {
+		return fmt.Errorf("could not store poll: %w", err)
+	}
+	for i, option := range options {
+		err := q.CreatePollOption(ctx, database.CreatePollOptionParams{
+			ChirpID:  chirpID,
+			Position: int32(i),
+			Text:     option,
+		})
+		if err != nil {
+			return fmt.Errorf("could not store poll option %q: %w", option, err)
+		}
+	}
+	return nil
+}
+
+// pollClosesAt returns when a chirp's poll closes. The duration counts from when the chirp is published,
+// so the poll of a scheduled chirp doesn't run down while it waits.
+func pollClosesAt(chirp database.Chirp, durationMinutes int32) time.Time {
+	start := chirp.CreatedAt
+	if chirp.PublishAt.Valid {
+		start = chirp.PublishAt.Time
+	}
+	return start.Add(time.Duration(durationMinutes) * time.Minute)
+}
+
+// loadChirpPolls fetches the polls attached to a batch of chirps as seen by the viewer (uuid.Nil for anonymous viewers), keyed by chirp id.
+// Vote counts are only included once the viewer has voted or the poll has closed. Chirps without a poll are absent from the returned map.
+func (cfg *ApiConfig) loadChirpPolls(ctx context.Context, viewerID uuid.UUID, chirps []database.Chirp) (map[uuid.UUID]*Poll, error) {
+	polls := make(map[uuid.UUID]*Poll)
+	if len(chirps) == 0 {
+		return polls, nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, c := range chirps {
+		chirpIDs = append(chirpIDs, c.ID)
+	}
+	dbPolls, err := cfg.DbQueries.GetPollsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(dbPolls) == 0 {
+		return polls, nil
+	}
+
+	// only chirps with a poll need their options and the viewer's votes loaded
+	pollChirpIDs := make([]uuid.UUID, 0, len(dbPolls))
+	durations := make(map[uuid.UUID]int32, len(dbPolls))
+	for _, p := range dbPolls {
+		pollChirpIDs = append(pollChirpIDs, p.ChirpID)
+		durations[p.ChirpID] = p.DurationMinutes
+	}
+	dbOptions, err := cfg.DbQueries.GetPollOptionsForChirps(ctx, pollChirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	votedOptions := make(map[uuid.UUID]int32)
+	if viewerID != uuid.Nil {
+		dbVotes, err := cfg.DbQueries.GetPollVotesByUser(ctx, database.GetPollVotesByUserParams{
+			UserID:   viewerID,
+			ChirpIds: pollChirpIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range dbVotes {
+			votedOptions[v.ChirpID] = v.Position
+		}
+	}
+
+	for _, c := range chirps {
+		duration, found := durations[c.ID]
+		if !found {
+			continue
+		}
+		poll := &Poll{ClosesAt: pollClosesAt(c, duration), Options: []PollOption{}}
+		poll.Closed = !time.Now().Before(poll.ClosesAt)
+		if position, voted := votedOptions[c.ID]; voted {
+			poll.VotedOption = &position
+		}
+		poll.ResultsVisible = poll.Closed || poll.VotedOption != nil
+		if poll.ResultsVisible {
+			poll.TotalVotes = new(int64)
+		}
+		polls[c.ID] = poll
+	}
+	for _, o := range dbOptions {
+		poll := polls[o.ChirpID]
+		option := PollOption{Text: o.Text}
+		if poll.ResultsVisible {
+			votes := o.Votes
+			option.Votes = &votes
+			*poll.TotalVotes += votes
+		}
+		poll.Options = append(poll.Options, option)
+	}
+
+	return polls, nil
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/logging"
+	"github.com/rickNoise/chirpy/internal/stream"
+)
+
+// publishEvent broadcasts an event to real-time subscribers. It never blocks on slow subscribers.
+// payload is marshalled to JSON and sent to clients as the event's data; e.Data is overwritten.
+//
+// When a Postgres bridge is configured the event is sent to every instance via NOTIFY, falling back to this instance's hub if that fails.
+func (cfg *ApiConfig) publishEvent(ctx context.Context, e stream.Event, payload interface{}) {
+	if cfg.Stream == nil {
+		return
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logging.FromContext(ctx).Error("could not marshal event", "event_type", e.Type, "error", err)
+		return
+	}
+	e.Data = data
+
+	if cfg.Broadcaster != nil {
+		err := cfg.Broadcaster.Publish(ctx, e)
+		if err == nil {
+			return
+		}
+		logging.FromContext(ctx).Error("could not broadcast event to other instances", "event_type", e.Type, "error", err)
+	}
+	cfg.Stream.Publish(e)
+}
+
+// publishChirpEvent broadcasts an event about a chirp, along with the chirp's audience (its visibility, its mentions and whether the author's account is private)
+// so that subscribers only deliver it to users allowed to see the chirp. Chirps hidden by moderation aren't announced at all.
+func (cfg *ApiConfig) publishChirpEvent(ctx context.Context, eventType string, chirp database.Chirp, payload interface{}) {
+	// if moderation can't be checked, err on the side of hiding the chirp
+	hiddenChirpIDs, err := cfg.DbQueries.GetModeratedChirpIDs(ctx, []uuid.UUID{chirp.ID})
+	if err != nil {
+		logging.FromContext(ctx).Error("could not check whether chirp is hidden by moderation", "chirp_id", chirp.ID, "error", err)
+		return
+	}
+	if len(hiddenChirpIDs) > 0 {
+		return
+	}
+
+	e := stream.Event{
+		Type:       eventType,
+		AuthorID:   chirp.UserID,
+		Visibility: chirp.Visibility,
+	}
+
+	// if the audience can't be loaded, err on the side of hiding the chirp
+	privateAuthorIDs, err := cfg.DbQueries.GetPrivateUserIDs(ctx, []uuid.UUID{chirp.UserID})
+	if err != nil {
+		logging.FromContext(ctx).Error("could not check whether user is private", "user_id", chirp.UserID, "error", err)
+		e.AuthorPrivate = true
+	} else {
+		e.AuthorPrivate = len(privateAuthorIDs) > 0
+	}
+	mentions, err := cfg.DbQueries.GetMentionsForChirps(ctx, []uuid.UUID{chirp.ID})
+	if err != nil {
+		logging.FromContext(ctx).Error("could not load mentions of chirp", "chirp_id", chirp.ID, "error", err)
+	}
+	for _, m := range mentions {
+		e.MentionedUserIDs = append(e.MentionedUserIDs, m.UserID)
+	}
+
+	cfg.publishEvent(ctx, e, payload)
+}
+
+// chirpEventAudience returns the audience of the chirp a chirp event is about.
+func chirpEventAudience(e stream.Event) chirpAudience {
+	return chirpAudience{
+		authorID:         e.AuthorID,
+		authorPrivate:    e.AuthorPrivate,
+		visibility:       e.Visibility,
+		mentionedUserIDs: e.MentionedUserIDs,
+	}
+}
+
+// publishUserEvent broadcasts that a user changed, so other instances can invalidate anything they hold about them.
+// Only the user's id is sent; subscribers fetch fresh data if they need it.
+func (cfg *ApiConfig) publishUserEvent(ctx context.Context, eventType string, userID uuid.UUID) {
+	type UserEvent struct {
+		Id uuid.UUID `json:"id"`
+	}
+	cfg.publishEvent(ctx, stream.Event{
+		Type:     eventType,
+		AuthorID: userID,
+	}, UserEvent{Id: userID})
+}
+
+// publishRelationshipsChanged tells open streams that the provided users' blocks, mutes or follows changed, so they reload them.
+func (cfg *ApiConfig) publishRelationshipsChanged(ctx context.Context, userIDs ...uuid.UUID) {
+	for _, userID := range userIDs {
+		cfg.publishUserEvent(ctx, stream.UserRelationshipsChanged, userID)
+	}
+}
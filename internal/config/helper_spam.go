@@ -0,0 +1,136 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/chirptext"
+	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/logging"
+	"github.com/rickNoise/chirpy/internal/spam"
+)
+
+// statuses of flagged chirps and accounts, as stored in chirp_moderation.status and user_moderation.status
+const (
+	moderationStatusQueued = "queued"
+	moderationStatusHidden = "hidden"
+)
+
+// short chirps like "gm" are posted word for word by plenty of real users, so only longer bodies count as duplicates
+const minDuplicateBodyLength = 20
+
+// NewChirpSpamPipeline creates the pipeline that scores new chirps:
+// the same body posted repeatedly in the last hour (by anyone), chirps that are mostly links, brand new accounts, and users posting in rapid bursts.
+func (cfg *ApiConfig) NewChirpSpamPipeline(t spam.Thresholds) *spam.Pipeline {
+	return spam.NewPipeline(t).
+		Add(spam.RecentCount{
+			SignalName: "duplicate_body",
+			Low:        1,
+			High:       4,
+			Count: func(ctx context.Context, s spam.Subject) (int, error) {
+				if chirptext.Length(s.Body) < minDuplicateBodyLength {
+					return 0, nil
+				}
+				n, err := cfg.DbQueries.CountRecentChirpsWithBody(ctx, database.CountRecentChirpsWithBodyParams{
+					Body:  s.Body,
+					Since: time.Now().UTC().Add(-time.Hour),
+				})
+				return int(n), err
+			},
+		}, 0.6).
+		Add(spam.RecentCount{
+			SignalName: "posting_velocity",
+			Low:        10,
+			High:       30,
+			Count: func(ctx context.Context, s spam.Subject) (int, error) {
+				n, err := cfg.DbQueries.CountRecentChirpsByUser(ctx, database.CountRecentChirpsByUserParams{
+					UserID: s.UserID,
+					Since:  time.Now().UTC().Add(-10 * time.Minute),
+				})
+				return int(n), err
+			},
+		}, 0.5).
+		Add(spam.LinkDensity{}, 0.3).
+		Add(spam.AccountAge{Min: time.Hour, Max: 24 * time.Hour}, 0.15)
+}
+
+// NewSignupSpamPipeline creates the pipeline that scores new accounts, by how many accounts were created at the same email domain in the last hour.
+// Its weight keeps it short of rejecting on its own, since a burst of signups from a big email provider can be legitimate.
+func (cfg *ApiConfig) NewSignupSpamPipeline(t spam.Thresholds) *spam.Pipeline {
+	return spam.NewPipeline(t).
+		Add(spam.RecentCount{
+			SignalName: "signup_velocity",
+			Low:        5,
+			High:       20,
+			Count: func(ctx context.Context, s spam.Subject) (int, error) {
+				_, domain, found := strings.Cut(s.Email, "@")
+				if !found || domain == "" {
+					return 0, nil
+				}
+				n, err := cfg.DbQueries.CountRecentSignupsForEmailDomain(ctx, database.CountRecentSignupsForEmailDomainParams{
+					Domain: domain,
+					Since:  time.Now().UTC().Add(-time.Hour),
+				})
+				return int(n), err
+			},
+		}, 0.8)
+}
+
+// checkSpam scores a subject with the provided pipeline, which may be nil to allow everything.
+// Spam checks fail open: if a signal can't be computed the subject is allowed, so an outage doesn't stop everyone from posting.
+func checkSpam(ctx context.Context, p *spam.Pipeline, s spam.Subject) spam.Verdict {
+	if p == nil {
+		return spam.Verdict{Action: spam.ActionAllow}
+	}
+	verdict, err := p.Evaluate(ctx, s)
+	if err != nil {
+		logging.FromContext(ctx).Error("could not check for spam", "error", err)
+	}
+	return verdict
+}
+
+// checkChirpSpam scores a chirp body the provided user is about to create, or edit into, with the chirp spam pipeline.
+// Every path that creates a chirp or changes its body goes through it, so none of them can be used to get around the pipeline.
+// Likely spam gets a 400 response and ok=false; otherwise the verdict should be stored with flagChirp once the chirp is saved.
+func (cfg *ApiConfig) checkChirpSpam(w http.ResponseWriter, r *http.Request, dbUser database.User, body string) (verdict spam.Verdict, ok bool) {
+	verdict = checkSpam(r.Context(), cfg.ChirpSpam, spam.Subject{
+		UserID:           dbUser.ID,
+		Body:             body,
+		AccountCreatedAt: dbUser.CreatedAt,
+	})
+	if verdict.Action == spam.ActionReject {
+		respondWithError(w, http.StatusBadRequest, "chirp rejected as likely spam", fmt.Errorf("spam score %.2f: %s", verdict.Score, verdict.Reasons()))
+		return verdict, false
+	}
+	return verdict, true
+}
+
+// flagChirp stores the moderation flag, if any, that a checkChirpSpam verdict puts on a chirp. A chirp that is already flagged keeps its flag.
+func flagChirp(ctx context.Context, q *database.Queries, chirpID uuid.UUID, verdict spam.Verdict) error {
+	status := moderationStatus(verdict)
+	if status == "" {
+		return nil
+	}
+	return q.CreateChirpModeration(ctx, database.CreateChirpModerationParams{
+		ChirpID: chirpID,
+		Status:  status,
+		Score:   verdict.Score,
+		Reasons: verdict.Reasons(),
+	})
+}
+
+// moderationStatus returns the moderation status a verdict flags its subject with, or "" if it isn't flagged.
+func moderationStatus(v spam.Verdict) string {
+	switch v.Action {
+	case spam.ActionQueue:
+		return moderationStatusQueued
+	case spam.ActionShadow:
+		return moderationStatusHidden
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,273 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+)
+
+// chirp visibilities, as stored in chirps.visibility
+const (
+	chirpVisibilityPublic    = "public"
+	chirpVisibilityFollowers = "followers"
+	chirpVisibilityMentioned = "mentioned"
+	chirpVisibilityUnlisted  = "unlisted"
+)
+
+var chirpVisibilities = []string{chirpVisibilityPublic, chirpVisibilityFollowers, chirpVisibilityMentioned, chirpVisibilityUnlisted}
+
+// A chirpViewer is whoever is reading chirps, along with the relationships that decide which chirps they may see.
+// Deleted chirps and deleted accounts are already excluded by the queries; everything that depends on the viewer is decided here,
+// so that every read path applies the same rules.
+type chirpViewer struct {
+	userID    uuid.UUID              // uuid.Nil for anonymous viewers
+	blocked   map[uuid.UUID]struct{} // users the viewer has blocked or been blocked by
+	muted     map[uuid.UUID]struct{} // users the viewer has muted
+	following map[uuid.UUID]struct{} // users the viewer follows (accepted follows only)
+}
+
+// loadChirpViewer loads the relationships of the viewing user; userID may be uuid.Nil for an anonymous viewer.
+func (cfg *ApiConfig) loadChirpViewer(ctx context.Context, userID uuid.UUID) (chirpViewer, error) {
+	viewer := chirpViewer{
+		userID:    userID,
+		blocked:   make(map[uuid.UUID]struct{}),
+		muted:     make(map[uuid.UUID]struct{}),
+		following: make(map[uuid.UUID]struct{}),
+	}
+	if userID == uuid.Nil {
+		return viewer, nil
+	}
+
+	blocked, err := cfg.DbQueries.GetBlockRelatedUserIDs(ctx, userID)
+	if err != nil {
+		return viewer, fmt.Errorf("could not load blocks: %w", err)
+	}
+	for _, id := range blocked {
+		viewer.blocked[id] = struct{}{}
+	}
+
+	muted, err := cfg.DbQueries.GetMutedUserIDs(ctx, userID)
+	if err != nil {
+		return viewer, fmt.Errorf("could not load mutes: %w", err)
+	}
+	for _, id := range muted {
+		viewer.muted[id] = struct{}{}
+	}
+
+	following, err := cfg.DbQueries.GetFolloweeIDs(ctx, userID)
+	if err != nil {
+		return viewer, fmt.Errorf("could not load follows: %w", err)
+	}
+	for _, id := range following {
+		viewer.following[id] = struct{}{}
+	}
+
+	return viewer, nil
+}
+
+// hasBlocked reports whether the viewer and the provided user have blocked each other (in either direction).
+func (v chirpViewer) hasBlocked(userID uuid.UUID) bool {
+	_, blocked := v.blocked[userID]
+	return blocked
+}
+
+// hasMuted reports whether the viewer has muted the provided user.
+func (v chirpViewer) hasMuted(userID uuid.UUID) bool {
+	_, muted := v.muted[userID]
+	return muted
+}
+
+// follows reports whether the viewer is an approved follower of the provided user.
+func (v chirpViewer) follows(userID uuid.UUID) bool {
+	_, following := v.following[userID]
+	return following
+}
+
+// canSeeAuthor reports whether the viewer may see chirps by an author at all, e.g. when fetching one directly.
+// Private accounts' chirps are only visible to the author and their approved followers.
+func (v chirpViewer) canSeeAuthor(authorID uuid.UUID, authorIsPrivate bool) bool {
+	if authorID == v.userID {
+		return true
+	}
+	if v.hasBlocked(authorID) {
+		return false
+	}
+	return !authorIsPrivate || v.follows(authorID)
+}
+
+// A chirpAudience holds what, besides the viewer's own relationships, decides who may see a chirp.
+type chirpAudience struct {
+	authorID         uuid.UUID
+	authorPrivate    bool
+	visibility       string
+	mentionedUserIDs []uuid.UUID
+	hidden           bool // flagged by the spam pipeline, or written by a flagged account
+}
+
+// canSee reports whether the viewer may see a chirp. Timelines, search results and other feeds pass feed=true,
+// which also leaves out muted users and unlisted chirps; authors always see their own chirps, even when they are hidden by moderation.
+func (v chirpViewer) canSee(a chirpAudience, feed bool) bool {
+	if a.authorID == v.userID {
+		return true
+	}
+	if a.hidden {
+		return false
+	}
+	if !v.canSeeAuthor(a.authorID, a.authorPrivate) {
+		return false
+	}
+
+	switch a.visibility {
+	case chirpVisibilityFollowers:
+		if !v.follows(a.authorID) {
+			return false
+		}
+	case chirpVisibilityMentioned:
+		if v.userID == uuid.Nil || !slices.Contains(a.mentionedUserIDs, v.userID) {
+			return false
+		}
+	case chirpVisibilityUnlisted:
+		if feed {
+			return false
+		}
+	}
+
+	return !feed || !v.hasMuted(a.authorID)
+}
+
+// loadChirpAudiences loads the audience of each of the provided chirps, keyed by chirp id.
+func (cfg *ApiConfig) loadChirpAudiences(ctx context.Context, chirps []database.Chirp) (map[uuid.UUID]chirpAudience, error) {
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	authorIDs := make([]uuid.UUID, 0, len(chirps))
+	mentionedOnlyChirpIDs := []uuid.UUID{}
+	for _, c := range chirps {
+		chirpIDs = append(chirpIDs, c.ID)
+		authorIDs = append(authorIDs, c.UserID)
+		if c.Visibility == chirpVisibilityMentioned {
+			mentionedOnlyChirpIDs = append(mentionedOnlyChirpIDs, c.ID)
+		}
+	}
+
+	privateAuthorIDs, err := cfg.DbQueries.GetPrivateUserIDs(ctx, authorIDs)
+	if err != nil {
+		return nil, fmt.Errorf("could not load private accounts: %w", err)
+	}
+
+	hiddenChirpIDs, err := cfg.DbQueries.GetModeratedChirpIDs(ctx, chirpIDs)
+	if err != nil {
+		return nil, fmt.Errorf("could not load moderated chirps: %w", err)
+	}
+
+	// mentions only matter for chirps addressed to the mentioned users
+	mentionedUserIDs := make(map[uuid.UUID][]uuid.UUID)
+	if len(mentionedOnlyChirpIDs) > 0 {
+		mentions, err := cfg.DbQueries.GetMentionsForChirps(ctx, mentionedOnlyChirpIDs)
+		if err != nil {
+			return nil, fmt.Errorf("could not load mentions: %w", err)
+		}
+		for _, m := range mentions {
+			mentionedUserIDs[m.ChirpID] = append(mentionedUserIDs[m.ChirpID], m.UserID)
+		}
+	}
+
+	audiences := make(map[uuid.UUID]chirpAudience, len(chirps))
+	for _, c := range chirps {
+		audiences[c.ID] = chirpAudience{
+			authorID:         c.UserID,
+			authorPrivate:    slices.Contains(privateAuthorIDs, c.UserID),
+			visibility:       c.Visibility,
+			mentionedUserIDs: mentionedUserIDs[c.ID],
+			hidden:           slices.Contains(hiddenChirpIDs, c.ID),
+		}
+	}
+	return audiences, nil
+}
+
+// filterChirpsForViewer returns the chirps the viewing user (uuid.Nil if anonymous) may see, keeping their order.
+// Timelines and search results pass feed=true to also leave out muted users and unlisted chirps.
+func (cfg *ApiConfig) filterChirpsForViewer(ctx context.Context, viewerID uuid.UUID, chirps []database.Chirp, feed bool) ([]database.Chirp, error) {
+	viewer, err := cfg.loadChirpViewer(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	audiences, err := cfg.loadChirpAudiences(ctx, chirps)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]database.Chirp, 0, len(chirps))
+	for _, c := range chirps {
+		if viewer.canSee(audiences[c.ID], feed) {
+			visible = append(visible, c)
+		}
+	}
+	return visible, nil
+}
+
+// visibleChirpsInOrder loads the chirps with the provided ids that the viewer may see, in the order of the ids, ready for an API response.
+// It is used for lists of chirps kept outside the chirps table, such as bookmarks and collections.
+func (cfg *ApiConfig) visibleChirpsInOrder(ctx context.Context, viewerID uuid.UUID, chirpIDs []uuid.UUID) ([]Chirp, error) {
+	dbChirps, err := cfg.DbQueries.GetChirpsByIDs(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	chirpsByID := make(map[uuid.UUID]database.Chirp, len(dbChirps))
+	for _, c := range dbChirps {
+		chirpsByID[c.ID] = c
+	}
+	ordered := make([]database.Chirp, 0, len(dbChirps))
+	for _, id := range chirpIDs {
+		if c, found := chirpsByID[id]; found {
+			ordered = append(ordered, c)
+		}
+	}
+
+	ordered, err = cfg.filterChirpsForViewer(ctx, viewerID, ordered, false)
+	if err != nil {
+		return nil, err
+	}
+	jsonChirps, err := cfg.databaseChirpsToAPIChirps(ctx, viewerID, ordered)
+	if err != nil {
+		return nil, err
+	}
+	if jsonChirps == nil {
+		jsonChirps = []Chirp{}
+	}
+	return jsonChirps, nil
+}
+
+// parseVisibleChirpRequest authenticates the user and loads the {chirpID} they are acting on,
+// writing a 404 if the chirp doesn't exist or the user isn't allowed to see it.
+func (cfg *ApiConfig) parseVisibleChirpRequest(w http.ResponseWriter, r *http.Request) (userID uuid.UUID, chirp database.Chirp, ok bool) {
+	userID, ok = cfg.authenticateUser(w, r)
+	if !ok {
+		return uuid.Nil, database.Chirp{}, false
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid chirp id", err)
+		return uuid.Nil, database.Chirp{}, false
+	}
+
+	chirp, err = cfg.DbQueries.GetChirp(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "chirp not found", err)
+		return uuid.Nil, database.Chirp{}, false
+	}
+	visibleChirps, err := cfg.filterChirpsForViewer(r.Context(), userID, []database.Chirp{chirp}, false)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "could not get chirp", err)
+		return uuid.Nil, database.Chirp{}, false
+	}
+	if len(visibleChirps) == 0 {
+		respondWithError(w, http.StatusNotFound, "chirp not found", nil)
+		return uuid.Nil, database.Chirp{}, false
+	}
+
+	return userID, chirp, true
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestChirpViewerCanSee(t *testing.T) {
+	viewerID, authorID, otherID := uuid.New(), uuid.New(), uuid.New()
+	viewer := func(blocked, muted, following bool) chirpViewer {
+		v := chirpViewer{
+			userID:    viewerID,
+			blocked:   map[uuid.UUID]struct{}{},
+			muted:     map[uuid.UUID]struct{}{},
+			following: map[uuid.UUID]struct{}{},
+		}
+		if blocked {
+			v.blocked[authorID] = struct{}{}
+		}
+		if muted {
+			v.muted[authorID] = struct{}{}
+		}
+		if following {
+			v.following[authorID] = struct{}{}
+		}
+		return v
+	}
+	stranger := viewer(false, false, false)
+	follower := viewer(false, false, true)
+	anonymous := chirpViewer{userID: uuid.Nil}
+
+	public := chirpAudience{authorID: authorID, visibility: chirpVisibilityPublic}
+	withAudience := func(change func(a *chirpAudience)) chirpAudience {
+		a := public
+		change(&a)
+		return a
+	}
+
+	cases := []struct {
+		name     string
+		viewer   chirpViewer
+		audience chirpAudience
+		feed     bool
+		expected bool
+	}{
+		{name: "public chirp", viewer: stranger, audience: public, expected: true},
+		{name: "public chirp, anonymous", viewer: anonymous, audience: public, expected: true},
+		{name: "author sees their own hidden followers-only chirp", viewer: chirpViewer{userID: authorID},
+			audience: withAudience(func(a *chirpAudience) { a.visibility = chirpVisibilityFollowers; a.hidden = true }), feed: true, expected: true},
+		{name: "blocked", viewer: viewer(true, false, true), audience: public, expected: false},
+		{name: "private account, not following", viewer: stranger,
+			audience: withAudience(func(a *chirpAudience) { a.authorPrivate = true }), expected: false},
+		{name: "private account, following", viewer: follower,
+			audience: withAudience(func(a *chirpAudience) { a.authorPrivate = true }), expected: true},
+		{name: "private account, anonymous", viewer: anonymous,
+			audience: withAudience(func(a *chirpAudience) { a.authorPrivate = true }), expected: false},
+		{name: "followers-only, not following", viewer: stranger,
+			audience: withAudience(func(a *chirpAudience) { a.visibility = chirpVisibilityFollowers }), expected: false},
+		{name: "followers-only, following", viewer: follower,
+			audience: withAudience(func(a *chirpAudience) { a.visibility = chirpVisibilityFollowers }), expected: true},
+		{name: "mentioned-only, mentioned", viewer: stranger,
+			audience: withAudience(func(a *chirpAudience) {
+				a.visibility = chirpVisibilityMentioned
+				a.mentionedUserIDs = []uuid.UUID{otherID, viewerID}
+			}), expected: true},
+		{name: "mentioned-only, not mentioned", viewer: follower,
+			audience: withAudience(func(a *chirpAudience) {
+				a.visibility = chirpVisibilityMentioned
+				a.mentionedUserIDs = []uuid.UUID{otherID}
+			}), expected: false},
+		{name: "mentioned-only, anonymous", viewer: anonymous,
+			audience: withAudience(func(a *chirpAudience) { a.visibility = chirpVisibilityMentioned }), expected: false},
+		{name: "unlisted, fetched directly", viewer: stranger,
+			audience: withAudience(func(a *chirpAudience) { a.visibility = chirpVisibilityUnlisted }), expected: true},
+		{name: "unlisted, in a feed", viewer: stranger,
+			audience: withAudience(func(a *chirpAudience) { a.visibility = chirpVisibilityUnlisted }), feed: true, expected: false},
+		{name: "muted, fetched directly", viewer: viewer(false, true, false), audience: public, expected: true},
+		{name: "muted, in a feed", viewer: viewer(false, true, false), audience: public, feed: true, expected: false},
+		{name: "hidden by moderation", viewer: follower,
+			audience: withAudience(func(a *chirpAudience) { a.hidden = true }), expected: false},
+	}
+	for _, c := range cases {
+		if got := c.viewer.canSee(c.audience, c.feed); got != c.expected {
+			t.Errorf("%s: canSee = %t, expected %t", c.name, got, c.expected)
+		}
+	}
+}
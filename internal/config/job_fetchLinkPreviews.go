@@ -0,0 +1,132 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/linkpreview"
+)
+
+// urls left in the fetching state for longer than this (e.g. because an instance crashed mid-way) are retried
+const linkPreviewFetchStaleAfter = 5 * time.Minute
+
+// enqueueChirpLinkPreview hands a new chirp's link to the fetch workers without blocking the request.
+// If the queue is full (or the preview was already cached) the worker finds nothing to do, and pending links are picked up by the next sweep.
+func (cfg *ApiConfig) enqueueChirpLinkPreview(chirp database.Chirp) {
+	previewURL, found := chirpPreviewURL(chirp.Body)
+	if !found || cfg.LinkPreviewQueue == nil {
+		return
+	}
+	select {
+	case cfg.LinkPreviewQueue <- previewURL:
+	default:
+	}
+}
+
+// RunLinkPreviewFetcher runs a pool of workers that fetch link previews from cfg.LinkPreviewQueue until ctx is cancelled.
+// Once per sweepInterval it also re-queues pending links, which covers links queued while the queue was full, by other instances, or before a crash.
+// It is intended to be run in its own goroutine.
+func (cfg *ApiConfig) RunLinkPreviewFetcher(ctx context.Context, workers int, sweepInterval time.Duration) {
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case previewURL := <-cfg.LinkPreviewQueue:
+					if err := cfg.fetchLinkPreview(ctx, previewURL); err != nil {
+						slog.Warn("could not fetch link preview", "url", previewURL, "error", err)
+					}
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.sweepPendingLinkPreviews(ctx); err != nil {
+			slog.Error("could not queue pending link previews", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweepPendingLinkPreviews resets stale fetches and queues as many pending links as there is room for.
+func (cfg *ApiConfig) sweepPendingLinkPreviews(ctx context.Context) error {
+	if _, err := cfg.DbQueries.ResetStaleLinkPreviewFetches(ctx, time.Now().UTC().Add(-linkPreviewFetchStaleAfter)); err != nil {
+		return fmt.Errorf("could not reset stale link previews: %w", err)
+	}
+
+	room := cap(cfg.LinkPreviewQueue) - len(cfg.LinkPreviewQueue)
+	if room <= 0 {
+		return nil
+	}
+	pending, err := cfg.DbQueries.GetPendingLinkPreviewURLs(ctx, int32(room))
+	if err != nil {
+		return err
+	}
+	for _, previewURL := range pending {
+		select {
+		case cfg.LinkPreviewQueue <- previewURL:
+		default:
+		}
+	}
+	return nil
+}
+
+// fetchLinkPreview claims a pending link and stores its preview. Links without a usable preview are marked failed, which is cached too,
+// so a broken or hostile site isn't fetched again for every chirp linking to it.
+func (cfg *ApiConfig) fetchLinkPreview(ctx context.Context, previewURL string) error {
+	_, err := cfg.DbQueries.ClaimLinkPreviewForFetching(ctx, previewURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil // already claimed or fetched
+	}
+	if err != nil {
+		return fmt.Errorf("could not claim link preview: %w", err)
+	}
+
+	preview, err := cfg.LinkPreviews.Fetch(ctx, previewURL)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err // shutting down; the stale sweep will retry it
+		}
+		if failErr := cfg.DbQueries.FailLinkPreview(ctx, previewURL); failErr != nil {
+			slog.Error("could not mark link preview as failed", "url", previewURL, "error", failErr)
+		}
+		// links to pages without a preview are common and expected, so they aren't reported as errors
+		if errors.Is(err, linkpreview.ErrNotHTML) || errors.Is(err, linkpreview.ErrNoMetadata) {
+			return nil
+		}
+		return err
+	}
+
+	err = cfg.DbQueries.CompleteLinkPreview(ctx, database.CompleteLinkPreviewParams{
+		CanonicalUrl: preview.URL,
+		Title:        preview.Title,
+		Description:  preview.Description,
+		ImageUrl:     preview.ImageURL,
+		SiteName:     preview.SiteName,
+		Url:          previewURL,
+	})
+	if err != nil {
+		return fmt.Errorf("could not store link preview: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/media"
+)
+
+// media left in the processing state for longer than this (e.g. because an instance crashed mid-way) is retried
+const mediaProcessingStaleAfter = 5 * time.Minute
+
+// enqueueMedia hands an uploaded media item to the processing workers without blocking the request.
+// If the queue is full the item stays pending and is picked up by the next sweep instead.
+func (cfg *ApiConfig) enqueueMedia(mediaID uuid.UUID) {
+	if cfg.MediaQueue == nil {
+		return
+	}
+	select {
+	case cfg.MediaQueue <- mediaID:
+	default:
+	}
+}
+
+// RunMediaProcessor runs a pool of workers that process uploaded media from cfg.MediaQueue until ctx is cancelled.
+// Once per sweepInterval it also re-queues media that is still pending, which covers uploads made while the queue was full, uploads handled by other instances, and work interrupted by a crash.
+// It is intended to be run in its own goroutine.
+func (cfg *ApiConfig) RunMediaProcessor(ctx context.Context, workers int, sweepInterval time.Duration) {
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case mediaID := <-cfg.MediaQueue:
+					if err := cfg.processMedia(ctx, mediaID); err != nil {
+						slog.Error("could not process media", "media_id", mediaID, "error", err)
+					}
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.sweepPendingMedia(ctx); err != nil {
+			slog.Error("could not queue pending media", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweepPendingMedia resets stale work and queues as much pending media as there is room for.
+func (cfg *ApiConfig) sweepPendingMedia(ctx context.Context) error {
+	if _, err := cfg.DbQueries.ResetStaleMediaProcessing(ctx, time.Now().UTC().Add(-mediaProcessingStaleAfter)); err != nil {
+		return fmt.Errorf("could not reset stale media: %w", err)
+	}
+
+	room := cap(cfg.MediaQueue) - len(cfg.MediaQueue)
+	if room <= 0 {
+		return nil
+	}
+	pending, err := cfg.DbQueries.GetPendingMediaIDs(ctx, int32(room))
+	if err != nil {
+		return err
+	}
+	for _, id := range pending {
+		cfg.enqueueMedia(id)
+	}
+	return nil
+}
+
+// processMedia claims a pending media item, replaces its blob with the processed (metadata-stripped) image, and stores its thumbnails and blurhash.
+// An id can be queued more than once; only the worker that claims it does the work.
+func (cfg *ApiConfig) processMedia(ctx context.Context, mediaID uuid.UUID) error {
+	dbMedia, err := cfg.DbQueries.ClaimMediaForProcessing(ctx, mediaID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil // already claimed, processed or deleted
+	}
+	if err != nil {
+		return fmt.Errorf("could not claim media: %w", err)
+	}
+
+	err = cfg.processClaimedMedia(ctx, dbMedia)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err // shutting down; the stale sweep will retry it
+		}
+		if failErr := cfg.DbQueries.FailMediaProcessing(ctx, mediaID); failErr != nil {
+			slog.Error("could not mark media as failed", "media_id", mediaID, "error", failErr)
+		}
+	}
+	return err
+}
+
+func (cfg *ApiConfig) processClaimedMedia(ctx context.Context, dbMedia database.Medium) error {
+	blob, err := cfg.Blobs.Get(ctx, dbMedia.StorageKey)
+	if err != nil {
+		return fmt.Errorf("could not read upload: %w", err)
+	}
+	data, err := io.ReadAll(blob)
+	blob.Close()
+	if err != nil {
+		return fmt.Errorf("could not read upload: %w", err)
+	}
+
+	processed, err := media.Process(data)
+	if err != nil {
+		return err
+	}
+
+	// the processed original replaces the upload in place, unless its format (and so its extension) changed
+	originalKey := dbMedia.ID.String() + processed.Original.Extension
+	if err := cfg.putEncoded(ctx, originalKey, processed.Original); err != nil {
+		return err
+	}
+
+	for _, thumb := range processed.Thumbnails {
+		key := dbMedia.ID.String() + "_" + thumb.Name + thumb.Extension
+		if err := cfg.putEncoded(ctx, key, thumb.Encoded); err != nil {
+			return err
+		}
+		err := cfg.DbQueries.CreateMediaThumbnail(ctx, database.CreateMediaThumbnailParams{
+			MediaID:     dbMedia.ID,
+			SizeName:    thumb.Name,
+			StorageKey:  key,
+			ContentType: thumb.ContentType,
+			Width:       int32(thumb.Width),
+			Height:      int32(thumb.Height),
+		})
+		if err != nil {
+			return fmt.Errorf("could not record %s thumbnail: %w", thumb.Name, err)
+		}
+	}
+
+	_, err = cfg.DbQueries.CompleteMediaProcessing(ctx, database.CompleteMediaProcessingParams{
+		StorageKey:  originalKey,
+		ContentType: processed.Original.ContentType,
+		SizeBytes:   int64(len(processed.Original.Data)),
+		Width:       int32(processed.Original.Width),
+		Height:      int32(processed.Original.Height),
+		Blurhash:    sql.NullString{String: processed.Blurhash, Valid: true},
+		ID:          dbMedia.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("could not complete processing: %w", err)
+	}
+
+	// best effort; an orphaned blob is harmless
+	if originalKey != dbMedia.StorageKey {
+		if err := cfg.Blobs.Delete(ctx, dbMedia.StorageKey); err != nil {
+			slog.Warn("could not delete blob", "key", dbMedia.StorageKey, "error", err)
+		}
+	}
+	return nil
+}
+
+func (cfg *ApiConfig) putEncoded(ctx context.Context, key string, e media.Encoded) error {
+	if err := cfg.Blobs.Put(ctx, key, bytes.NewReader(e.Data), int64(len(e.Data)), e.ContentType); err != nil {
+		return fmt.Errorf("could not store %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/stream"
+)
+
+// key of the Postgres advisory lock held while publishing scheduled chirps, so that only one instance publishes at a time
+const scheduledChirpsLockID int64 = 0x636869727079 // "chirpy"
+
+// maximum number of scheduled chirps published per run; any remainder is picked up by the next run
+const maxChirpsPerPublish = 500
+
+// RunChirpScheduler publishes scheduled chirps whose publish time has passed, immediately and then once per interval until ctx is cancelled.
+// It is safe to run on several instances at once. It is intended to be run in its own goroutine.
+func (cfg *ApiConfig) RunChirpScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.publishDueChirps(ctx); err != nil {
+			slog.Error("could not publish scheduled chirps", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishDueChirps publishes a batch of due chirps and announces them to real-time subscribers.
+// The batch is claimed under a transaction-scoped advisory lock; if another instance holds the lock, this run does nothing.
+func (cfg *ApiConfig) publishDueChirps(ctx context.Context) error {
+	tx, err := cfg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	locked, err := qtx.TryAdvisoryXactLock(ctx, scheduledChirpsLockID)
+	if err != nil {
+		return fmt.Errorf("could not take advisory lock: %w", err)
+	}
+	if !locked {
+		return nil // another instance is publishing
+	}
+
+	dbChirps, err := qtx.PublishDueChirps(ctx, maxChirpsPerPublish)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if len(dbChirps) == 0 {
+		return nil
+	}
+
+	// events are broadcast, so they are serialized as seen by an anonymous viewer
+	jsonChirps, err := cfg.databaseChirpsToAPIChirps(ctx, uuid.Nil, dbChirps)
+	if err != nil {
+		return fmt.Errorf("could not load published chirps: %w", err)
+	}
+	for i, c := range dbChirps {
+		cfg.publishChirpEvent(ctx, stream.ChirpCreated, c, jsonChirps[i])
+	}
+	return nil
+}
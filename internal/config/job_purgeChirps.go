@@ -0,0 +1,90 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+)
+
+// maximum number of chirps purged per run; any remainder is picked up by the next run
+const maxChirpsPerPurge = 500
+
+// RunChirpPurger permanently deletes chirps whose retention window has passed, immediately and then once per interval until ctx is cancelled.
+// It is intended to be run in its own goroutine.
+func (cfg *ApiConfig) RunChirpPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.purgeDeletedChirps(ctx); err != nil {
+			slog.Error("could not purge deleted chirps", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeDeletedChirps deletes a batch of expired chirps, which cascades to their entities and media.
+// Blobs live outside the database, so their keys are looked up first and deleted afterwards.
+func (cfg *ApiConfig) purgeDeletedChirps(ctx context.Context) error {
+	chirpIDs, err := cfg.DbQueries.GetChirpsDueForPurge(ctx, database.GetChirpsDueForPurgeParams{
+		DeletedBefore: sql.NullTime{Time: time.Now().UTC().Add(-chirpRetentionPeriod), Valid: true},
+		MaxChirps:     maxChirpsPerPurge,
+	})
+	if err != nil || len(chirpIDs) == 0 {
+		return err
+	}
+
+	blobKeys, err := cfg.chirpBlobKeys(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.DbQueries.PurgeChirps(ctx, chirpIDs); err != nil {
+		return err
+	}
+
+	cfg.deleteBlobs(ctx, blobKeys)
+	return nil
+}
+
+// chirpBlobKeys returns the storage keys of the media (and thumbnails) attached to the provided chirps,
+// which have to be looked up before the chirps are deleted.
+func (cfg *ApiConfig) chirpBlobKeys(ctx context.Context, chirpIDs []uuid.UUID) ([]string, error) {
+	attachedMedia, err := cfg.DbQueries.GetMediaForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	blobKeys := make([]string, 0, len(attachedMedia))
+	mediaIDs := make([]uuid.UUID, 0, len(attachedMedia))
+	for _, m := range attachedMedia {
+		blobKeys = append(blobKeys, m.StorageKey)
+		mediaIDs = append(mediaIDs, m.ID)
+	}
+	thumbnails, err := cfg.DbQueries.GetThumbnailsForMedia(ctx, mediaIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range thumbnails {
+		blobKeys = append(blobKeys, t.StorageKey)
+	}
+	return blobKeys, nil
+}
+
+// deleteBlobs deletes blobs whose database rows are already gone.
+func (cfg *ApiConfig) deleteBlobs(ctx context.Context, keys []string) {
+	// best effort; an orphaned blob is harmless
+	for _, key := range keys {
+		if err := cfg.Blobs.Delete(ctx, key); err != nil {
+			slog.Warn("could not delete blob", "key", key, "error", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rickNoise/chirpy/internal/database"
+)
+
+// maximum number of accounts purged per run; any remainder is picked up by the next run
+const maxUsersPerPurge = 100
+
+// RunUserPurger permanently deletes accounts whose deletion grace period has passed, immediately and then once per interval until ctx is cancelled.
+// It is intended to be run in its own goroutine.
+func (cfg *ApiConfig) RunUserPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.purgeDeletedUsers(ctx); err != nil {
+			slog.Error("could not purge deleted users", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *ApiConfig) purgeDeletedUsers(ctx context.Context) error {
+	userIDs, err := cfg.DbQueries.GetUsersDueForPurge(ctx, database.GetUsersDueForPurgeParams{
+		DeletedBefore: sql.NullTime{Time: time.Now().UTC().Add(-accountDeletionGracePeriod), Valid: true},
+		MaxUsers:      maxUsersPerPurge,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		if err := cfg.purgeUser(ctx, userID); err != nil {
+			return fmt.Errorf("could not purge user %s: %w", userID, err)
+		}
+	}
+	return nil
+}
+
+// purgeUser deletes a user row, which cascades to everything that references it.
+// Blobs live outside the database, so their keys are looked up first and deleted afterwards.
+func (cfg *ApiConfig) purgeUser(ctx context.Context, userID uuid.UUID) error {
+	userMedia, err := cfg.DbQueries.GetMediaForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	blobKeys := make([]string, 0, len(userMedia))
+	mediaIDs := make([]uuid.UUID, 0, len(userMedia))
+	for _, m := range userMedia {
+		blobKeys = append(blobKeys, m.StorageKey)
+		mediaIDs = append(mediaIDs, m.ID)
+	}
+	thumbnails, err := cfg.DbQueries.GetThumbnailsForMedia(ctx, mediaIDs)
+	if err != nil {
+		return err
+	}
+	for _, t := range thumbnails {
+		blobKeys = append(blobKeys, t.StorageKey)
+	}
+
+	if err := cfg.DbQueries.DeleteUserById(ctx, userID); err != nil {
+		return err
+	}
+
+	cfg.deleteBlobs(ctx, blobKeys)
+	return nil
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/trends"
+)
+
+// maximum number of trends materialized (and returned) per window
+const maxTrendsPerWindow = 20
+
+// key of the Postgres advisory lock held while rebuilding trends, so that only one instance rebuilds them at a time
+const trendsLockID int64 = 0x7472656e6473 // "trends"
+
+// RunTrendsRefresher recomputes the materialized trending hashtags for every window immediately, and then once per interval until ctx is cancelled.
+// It is safe to run on several instances at once. It is intended to be run in its own goroutine.
+func (cfg *ApiConfig) RunTrendsRefresher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, window := range trends.Windows {
+			if err := cfg.refreshTrends(ctx, window); err != nil {
+				slog.Error("could not refresh trends", "window", window.Name, "error", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshTrends rebuilds the materialized trends for a single window in one transaction, so readers never see a half-built window.
+// The rebuild happens under a transaction-scoped advisory lock; if another instance holds the lock, this run does nothing.
+func (cfg *ApiConfig) refreshTrends(ctx context.Context, window trends.Window) error {
+	tx, err := cfg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	locked, err := qtx.TryAdvisoryXactLock(ctx, trendsLockID)
+	if err != nil {
+		return fmt.Errorf("could not take advisory lock: %w", err)
+	}
+	if !locked {
+		return nil // another instance is refreshing
+	}
+
+	now := time.Now().UTC()
+	dbUsages, err := qtx.CountHashtagUsageForWindow(ctx, database.CountHashtagUsageForWindowParams{
+		WindowStart:         now.Add(-window.Length),
+		PreviousWindowStart: now.Add(-2 * window.Length),
+	})
+	if err != nil {
+		return fmt.Errorf("could not count hashtag usage: %w", err)
+	}
+
+	usages := make([]trends.Usage, 0, len(dbUsages))
+	for _, u := range dbUsages {
+		usages = append(usages, trends.Usage{
+			Tag:           u.Tag,
+			CurrentCount:  u.CurrentCount,
+			PreviousCount: u.PreviousCount,
+		})
+	}
+	ranked := trends.Rank(usages, maxTrendsPerWindow)
+
+	if err := qtx.DeleteTrendsForWindow(ctx, window.Name); err != nil {
+		return fmt.Errorf("could not clear old trends: %w", err)
+	}
+	for _, t := range ranked {
+		err := qtx.CreateTrend(ctx, database.CreateTrendParams{
+			WindowName:    window.Name,
+			Tag:           t.Tag,
+			Score:         t.Score,
+			CurrentCount:  int32(t.CurrentCount),
+			PreviousCount: int32(t.PreviousCount),
+			ComputedAt:    now,
+		})
+		if err != nil {
+			return fmt.Errorf("could not store trend %q: %w", t.Tag, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -7,8 +7,10 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
@@ -17,19 +19,35 @@ INSERT INTO
         created_at,
         updated_at,
         body,
-        user_id
+        user_id,
+        visibility,
+        publish_at
     )
-VALUES (NOW(), NOW(), $1, $2) RETURNING id, created_at, updated_at, body, user_id
+VALUES (
+        NOW(),
+        NOW(),
+        $1,
+        $2,
+        $3,
+        $4
+    ) RETURNING id, created_at, updated_at, body, user_id, deleted_at, visibility, publish_at
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body       string
+	UserID     uuid.UUID
+	Visibility string
+	PublishAt  sql.NullTime
 }
 
-// creates a new chirp in the db tied to the creating user
+// creates a new chirp in the db tied to the creating user; a chirp with a publish_at is scheduled rather than published
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.Visibility,
+		arg.PublishAt,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,15 +55,24 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.PublishAt,
 	)
 	return i, err
 }
 
 const deleteChirpById = `-- name: DeleteChirpById :one
-DELETE FROM chirps WHERE id = $1 RETURNING id, created_at, updated_at, body, user_id
+UPDATE chirps
+SET
+    updated_at = NOW(),
+    deleted_at = NOW()
+WHERE
+    id = $1
+    AND deleted_at IS NULL RETURNING id, created_at, updated_at, body, user_id, deleted_at, visibility, publish_at
 `
 
-// Deletes the chirp with the provided chirp id (uuid)
+// Soft-deletes the chirp with the provided chirp id (uuid); it can be restored until it is purged
 func (q *Queries) DeleteChirpById(ctx context.Context, chirpid uuid.UUID) (Chirp, error) {
 	row := q.db.QueryRowContext(ctx, deleteChirpById, chirpid)
 	var i Chirp
@@ -55,22 +82,60 @@ func (q *Queries) DeleteChirpById(ctx context.Context, chirpid uuid.UUID) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.PublishAt,
 	)
 	return i, err
 }
 
+const deleteScheduledChirp = `-- name: DeleteScheduledChirp :execrows
+DELETE FROM chirps
+WHERE
+    id = $1
+    AND user_id = $2
+    AND publish_at IS NOT NULL
+`
+
+type DeleteScheduledChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+// Cancels (permanently deletes) a scheduled chirp belonging to the provided user, unless it has already been published
+func (q *Queries) DeleteScheduledChirp(ctx context.Context, arg DeleteScheduledChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteScheduledChirp, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getAllChirps = `-- name: GetAllChirps :many
 SELECT
     id,
     created_at,
     updated_at,
     body,
-    user_id
+    user_id,
+    deleted_at,
+    visibility,
+    publish_at
 FROM chirps
+WHERE
+    deleted_at IS NULL
+    AND publish_at IS NULL
+    AND user_id IN (
+        SELECT id
+        FROM users
+        WHERE
+            deleted_at IS NULL
+    )
 ORDER BY created_at ASC
 `
 
 // Retrieves all chirps in ascending order by created_at.
+// Deleted chirps, and chirps by users whose accounts are pending deletion, are hidden from every read.
 func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 	rows, err := q.db.QueryContext(ctx, getAllChirps)
 	if err != nil {
@@ -86,6 +151,9 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.PublishAt,
 		); err != nil {
 			return nil, err
 		}
@@ -106,10 +174,21 @@ SELECT
     created_at,
     updated_at,
     body,
-    user_id
+    user_id,
+    deleted_at,
+    visibility,
+    publish_at
 FROM chirps
 WHERE
     user_id = $1
+    AND deleted_at IS NULL
+    AND publish_at IS NULL
+    AND user_id IN (
+        SELECT id
+        FROM users
+        WHERE
+            deleted_at IS NULL
+    )
 ORDER BY created_at ASC
 `
 
@@ -129,6 +208,9 @@ func (q *Queries) GetAllChirpsByAuthorUserId(ctx context.Context, userID uuid.UU
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.PublishAt,
 		); err != nil {
 			return nil, err
 		}
@@ -149,10 +231,21 @@ SELECT
     created_at,
     updated_at,
     body,
-    user_id
+    user_id,
+    deleted_at,
+    visibility,
+    publish_at
 FROM chirps
 WHERE
     id = $1
+    AND deleted_at IS NULL
+    AND publish_at IS NULL
+    AND user_id IN (
+        SELECT id
+        FROM users
+        WHERE
+            deleted_at IS NULL
+    )
 `
 
 // Retrieves a single chirp based on provided chirp id.
@@ -165,6 +258,370 @@ func (q *Queries) GetChirp(ctx context.Context, chirpid uuid.UUID) (Chirp, error
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const getChirpIncludingDeleted = `-- name: GetChirpIncludingDeleted :one
+SELECT id, created_at, updated_at, body, user_id, deleted_at, visibility, publish_at FROM chirps WHERE id = $1
+`
+
+// Retrieves a single chirp based on provided chirp id, even if it has been deleted.
+func (q *Queries) GetChirpIncludingDeleted(ctx context.Context, chirpid uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpIncludingDeleted, chirpid)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT
+    id,
+    created_at,
+    updated_at,
+    body,
+    user_id,
+    deleted_at,
+    visibility,
+    publish_at
+FROM chirps
+WHERE
+    id = ANY ($1::uuid[])
+    AND deleted_at IS NULL
+    AND publish_at IS NULL
+    AND user_id IN (
+        SELECT id
+        FROM users
+        WHERE
+            deleted_at IS NULL
+    )
+`
+
+// Retrieves the chirps with the provided ids, in no particular order; ids of hidden or missing chirps are skipped.
+func (q *Queries) GetChirpsByIDs(ctx context.Context, chirpIds []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsDueForPurge = `-- name: GetChirpsDueForPurge :many
+SELECT id
+FROM chirps
+WHERE
+    deleted_at < $1
+ORDER BY deleted_at ASC
+LIMIT $2
+`
+
+type GetChirpsDueForPurgeParams struct {
+	DeletedBefore sql.NullTime
+	MaxChirps     int32
+}
+
+// Retrieves the ids of chirps deleted before the provided time, oldest first.
+func (q *Queries) GetChirpsDueForPurge(ctx context.Context, arg GetChirpsDueForPurgeParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsDueForPurge, arg.DeletedBefore, arg.MaxChirps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeletedChirpsForUser = `-- name: GetDeletedChirpsForUser :many
+SELECT id, created_at, updated_at, body, user_id, deleted_at, visibility, publish_at
+FROM chirps
+WHERE
+    user_id = $1
+    AND deleted_at IS NOT NULL
+ORDER BY created_at ASC
+`
+
+// Retrieves the provided user's deleted chirps that haven't been purged yet; in ascending order by created_at.
+func (q *Queries) GetDeletedChirpsForUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getDeletedChirpsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getScheduledChirp = `-- name: GetScheduledChirp :one
+SELECT id, created_at, updated_at, body, user_id, deleted_at, visibility, publish_at
+FROM chirps
+WHERE
+    id = $1
+    AND user_id = $2
+    AND publish_at IS NOT NULL
+`
+
+type GetScheduledChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+// Retrieves one of the provided user's scheduled chirps.
+func (q *Queries) GetScheduledChirp(ctx context.Context, arg GetScheduledChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getScheduledChirp, arg.ID, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const getScheduledChirpsForUser = `-- name: GetScheduledChirpsForUser :many
+SELECT id, created_at, updated_at, body, user_id, deleted_at, visibility, publish_at
+FROM chirps
+WHERE
+    user_id = $1
+    AND publish_at IS NOT NULL
+ORDER BY publish_at ASC
+`
+
+// Retrieves the provided user's scheduled chirps, soonest first.
+func (q *Queries) GetScheduledChirpsForUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getScheduledChirpsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const publishDueChirps = `-- name: PublishDueChirps :many
+UPDATE chirps
+SET
+    created_at = NOW(),
+    updated_at = NOW(),
+    publish_at = NULL
+WHERE
+    id IN (
+        SELECT id
+        FROM chirps
+        WHERE
+            publish_at <= NOW()
+        ORDER BY publish_at ASC
+        LIMIT $1
+    ) RETURNING id, created_at, updated_at, body, user_id, deleted_at, visibility, publish_at
+`
+
+// Publishes scheduled chirps whose publish_at has passed, oldest first; created_at becomes the time they were published
+func (q *Queries) PublishDueChirps(ctx context.Context, maxChirps int32) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, publishDueChirps, maxChirps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeChirps = `-- name: PurgeChirps :exec
+DELETE FROM chirps WHERE id = ANY ($1::uuid[])
+`
+
+// Permanently deletes the provided chirps; their hashtags, mentions and media are removed by ON DELETE CASCADE
+func (q *Queries) PurgeChirps(ctx context.Context, chirpIds []uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, purgeChirps, pq.Array(chirpIds))
+	return err
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps
+SET
+    updated_at = NOW(),
+    deleted_at = NULL
+WHERE
+    id = $1
+    AND deleted_at IS NOT NULL RETURNING id, created_at, updated_at, body, user_id, deleted_at, visibility, publish_at
+`
+
+// Restores a deleted chirp
+func (q *Queries) RestoreChirp(ctx context.Context, chirpid uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, chirpid)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.PublishAt,
+	)
+	return i, err
+}
+
+const updateScheduledChirp = `-- name: UpdateScheduledChirp :one
+UPDATE chirps
+SET
+    updated_at = NOW(),
+    body = $1,
+    visibility = $2,
+    publish_at = $3
+WHERE
+    id = $4
+    AND user_id = $5
+    AND publish_at IS NOT NULL RETURNING id, created_at, updated_at, body, user_id, deleted_at, visibility, publish_at
+`
+
+type UpdateScheduledChirpParams struct {
+	Body       string
+	Visibility string
+	PublishAt  sql.NullTime
+	ID         uuid.UUID
+	UserID     uuid.UUID
+}
+
+// Updates a scheduled chirp belonging to the provided user; returns no rows if it has already been published
+func (q *Queries) UpdateScheduledChirp(ctx context.Context, arg UpdateScheduledChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateScheduledChirp,
+		arg.Body,
+		arg.Visibility,
+		arg.PublishAt,
+		arg.ID,
+		arg.UserID,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.PublishAt,
 	)
 	return i, err
 }
@@ -6,17 +6,185 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type AuditEvent struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	EventType string
+	Outcome   string
+	ActorID   uuid.NullUUID
+	TargetID  uuid.NullUUID
+	IpAddress string
+	UserAgent string
+	RequestID string
+	Details   json.RawMessage
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Body       string
+	UserID     uuid.UUID
+	DeletedAt  sql.NullTime
+	Visibility string
+	PublishAt  sql.NullTime
+}
+
+type ChirpHashtag struct {
+	ChirpID     uuid.UUID
+	Tag         string
+	StartOffset int32
+	EndOffset   int32
+}
+
+type ChirpLinkPreview struct {
+	ChirpID uuid.UUID
+	Url     string
+}
+
+type ChirpMention struct {
+	ChirpID     uuid.UUID
+	UserID      uuid.UUID
+	Handle      string
+	StartOffset int32
+	EndOffset   int32
+}
+
+type ChirpModeration struct {
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	Status    string
+	Score     float64
+	Reasons   string
+}
+
+type Collection struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	Name        string
+	Description string
+	IsPublic    bool
+}
+
+type CollectionChirp struct {
+	CollectionID uuid.UUID
+	ChirpID      uuid.UUID
+	Position     int32
+	AddedAt      time.Time
+}
+
+type Conversation struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+	UserAID   uuid.UUID
+	UserBID   uuid.UUID
+}
+
+type DirectMessage struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+	ReadAt         sql.NullTime
+}
+
+type Draft struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	Body       string
+	Visibility string
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	Status     string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type HashtagTrend struct {
+	WindowName    string
+	Tag           string
+	Score         float64
+	CurrentCount  int32
+	PreviousCount int32
+	ComputedAt    time.Time
+}
+
+type LinkPreview struct {
+	Url          string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	FetchStatus  string
+	FetchedAt    sql.NullTime
+	CanonicalUrl string
+	Title        string
+	Description  string
+	ImageUrl     string
+	SiteName     string
+}
+
+type MediaThumbnail struct {
+	MediaID     uuid.UUID
+	SizeName    string
+	StorageKey  string
+	ContentType string
+	Width       int32
+	Height      int32
+}
+
+type Medium struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	UserID           uuid.UUID
+	ChirpID          uuid.NullUUID
+	Position         sql.NullInt32
+	StorageKey       string
+	ContentType      string
+	SizeBytes        int64
+	Width            int32
+	Height           int32
+	ProcessingStatus string
+	Blurhash         sql.NullString
+	ProcessedAt      sql.NullTime
+}
+
+type Poll struct {
+	ChirpID         uuid.UUID
+	DurationMinutes int32
+}
+
+type PollOption struct {
+	ChirpID  uuid.UUID
+	Position int32
+	Text     string
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
 	UserID    uuid.UUID
+	Position  int32
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -35,4 +203,52 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    bool
+	Handle         sql.NullString
+	DisplayName    string
+	Bio            string
+	AvatarMediaID  uuid.NullUUID
+	DeletedAt      sql.NullTime
+	IsPrivate      bool
+}
+
+type UserBlock struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type UserList struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	Name        string
+	Description string
+	IsPublic    bool
+}
+
+type UserListMember struct {
+	ListID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type UserListSubscription struct {
+	ListID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type UserModeration struct {
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	Status    string
+	Score     float64
+	Reasons   string
+}
+
+type UserMute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
 }
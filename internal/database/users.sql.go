@@ -7,34 +7,69 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const cancelUserDeletion = `-- name: CancelUserDeletion :one
+UPDATE users
+SET
+    updated_at = NOW(),
+    deleted_at = NULL
+WHERE
+    id = $1 RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_media_id, deleted_at, is_private
+`
+
+// restores an account whose deletion is still within its grace period
+func (q *Queries) CancelUserDeletion(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, cancelUserDeletion, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.DeletedAt,
+		&i.IsPrivate,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO
     users (
         created_at,
         updated_at,
         email,
-        hashed_password
+        hashed_password,
+        handle
     )
 VALUES (
         NOW(),
         NOW(),
         $1,
-        $2
-    ) RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+        $2,
+        $3
+    ) RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_media_id, deleted_at, is_private
 `
 
 type CreateUserParams struct {
 	Email          string
 	Hashedpassword string
+	Handle         sql.NullString
 }
 
 // id PK for users has a default UUID generated, so can leave out here
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.Hashedpassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.Hashedpassword, arg.Handle)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -43,6 +78,12 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.DeletedAt,
+		&i.IsPrivate,
 	)
 	return i, err
 }
@@ -57,8 +98,75 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 	return err
 }
 
+const deleteUserById = `-- name: DeleteUserById :exec
+DELETE FROM users WHERE id = $1
+`
+
+// permanently deletes a user; their chirps, media, tokens and other rows are removed by ON DELETE CASCADE
+func (q *Queries) DeleteUserById(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUserById, id)
+	return err
+}
+
+const getActiveUserById = `-- name: GetActiveUserById :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_media_id, deleted_at, is_private FROM users WHERE id = $1 AND deleted_at IS NULL
+`
+
+// looks up a user unless their account is pending deletion
+func (q *Queries) GetActiveUserById(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getActiveUserById, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.DeletedAt,
+		&i.IsPrivate,
+	)
+	return i, err
+}
+
+const getPrivateUserIDs = `-- name: GetPrivateUserIDs :many
+SELECT id
+FROM users
+WHERE
+    id = ANY ($1::uuid[])
+    AND is_private
+`
+
+// Retrieves which of the provided user ids belong to private accounts.
+func (q *Queries) GetPrivateUserIDs(ctx context.Context, userIds []uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getPrivateUserIDs, pq.Array(userIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users WHERE email = $1
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_media_id, deleted_at, is_private FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, useremail string) (User, error) {
@@ -71,29 +179,285 @@ func (q *Queries) GetUserByEmail(ctx context.Context, useremail string) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.DeletedAt,
+		&i.IsPrivate,
+	)
+	return i, err
+}
+
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_media_id, deleted_at, is_private
+FROM users
+WHERE
+    LOWER(handle) = LOWER($1)
+    AND deleted_at IS NULL
+`
+
+// handles are unique regardless of case
+func (q *Queries) GetUserByHandle(ctx context.Context, handle string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.DeletedAt,
+		&i.IsPrivate,
 	)
 	return i, err
 }
 
-const updateEmailAndPasswordByUserId = `-- name: UpdateEmailAndPasswordByUserId :one
+const getUserById = `-- name: GetUserById :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_media_id, deleted_at, is_private FROM users WHERE id = $1
+`
+
+// includes accounts pending deletion; use GetActiveUserById when looking up a user someone else wants to interact with
+func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserById, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.DeletedAt,
+		&i.IsPrivate,
+	)
+	return i, err
+}
+
+const getUserDeletedAt = `-- name: GetUserDeletedAt :one
+SELECT deleted_at FROM users WHERE id = $1
+`
+
+// looks up only whether (and when) a user deleted their account, e.g. before accepting one of their access tokens
+func (q *Queries) GetUserDeletedAt(ctx context.Context, id uuid.UUID) (sql.NullTime, error) {
+	row := q.db.QueryRowContext(ctx, getUserDeletedAt, id)
+	var deletedAt sql.NullTime
+	err := row.Scan(&deletedAt)
+	return deletedAt, err
+}
+
+const getUserIDByAvatar = `-- name: GetUserIDByAvatar :one
+SELECT id
+FROM users
+WHERE
+    avatar_media_id = $1
+    AND deleted_at IS NULL
+LIMIT 1
+`
+
+// looks up the (not deleted) user using a media item as their avatar, if any
+func (q *Queries) GetUserIDByAvatar(ctx context.Context, mediaID uuid.NullUUID) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getUserIDByAvatar, mediaID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getUserSummaries = `-- name: GetUserSummaries :many
+SELECT id, handle, display_name, avatar_media_id
+FROM users
+WHERE
+    id = ANY ($1::uuid[])
+`
+
+type GetUserSummariesRow struct {
+	ID            uuid.UUID
+	Handle        sql.NullString
+	DisplayName   string
+	AvatarMediaID uuid.NullUUID
+}
+
+// Retrieves the public summary fields for all of the provided user ids, e.g. for embedding chirp authors.
+func (q *Queries) GetUserSummaries(ctx context.Context, userIds []uuid.UUID) ([]GetUserSummariesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserSummaries, pq.Array(userIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserSummariesRow
+	for rows.Next() {
+		var i GetUserSummariesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Handle,
+			&i.DisplayName,
+			&i.AvatarMediaID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsersByHandles = `-- name: GetUsersByHandles :many
+SELECT id, handle
+FROM users
+WHERE
+    LOWER(handle) = ANY ($1::text[])
+    AND deleted_at IS NULL
+`
+
+type GetUsersByHandlesRow struct {
+	ID     uuid.UUID
+	Handle sql.NullString
+}
+
+// looks up the ids of users whose handles match any of the provided (lowercased) handles
+func (q *Queries) GetUsersByHandles(ctx context.Context, handles []string) ([]GetUsersByHandlesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByHandles, pq.Array(handles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUsersByHandlesRow
+	for rows.Next() {
+		var i GetUsersByHandlesRow
+		if err := rows.Scan(&i.ID, &i.Handle); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsersDueForPurge = `-- name: GetUsersDueForPurge :many
+SELECT id
+FROM users
+WHERE
+    deleted_at < $1
+ORDER BY deleted_at ASC
+LIMIT $2
+`
+
+type GetUsersDueForPurgeParams struct {
+	DeletedBefore sql.NullTime
+	MaxUsers      int32
+}
+
+// Retrieves the ids of users whose deletion grace period ended before the provided time, oldest first.
+func (q *Queries) GetUsersDueForPurge(ctx context.Context, arg GetUsersDueForPurgeParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersDueForPurge, arg.DeletedBefore, arg.MaxUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const softDeleteUser = `-- name: SoftDeleteUser :one
+UPDATE users
+SET
+    updated_at = NOW(),
+    deleted_at = NOW()
+WHERE
+    id = $1 RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_media_id, deleted_at, is_private
+`
+
+// marks a user's account as deleted; it is hidden immediately and purged once the grace period has passed
+func (q *Queries) SoftDeleteUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, softDeleteUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.DeletedAt,
+		&i.IsPrivate,
+	)
+	return i, err
+}
+
+const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET
     updated_at = NOW(),
     email = $1,
-    hashed_password = $2
+    hashed_password = $2,
+    handle = $3,
+    display_name = $4,
+    bio = $5,
+    avatar_media_id = $6,
+    is_private = $7
 WHERE
-    id = $3 RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+    id = $8 RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_media_id, deleted_at, is_private
 `
 
-type UpdateEmailAndPasswordByUserIdParams struct {
-	Newemail          string
-	Newhashedpassword string
-	Userid            uuid.UUID
+type UpdateUserParams struct {
+	Email          string
+	HashedPassword string
+	Handle         sql.NullString
+	DisplayName    string
+	Bio            string
+	AvatarMediaID  uuid.NullUUID
+	IsPrivate      bool
+	ID             uuid.UUID
 }
 
-// updates a user record with a new hashed password and email address
-func (q *Queries) UpdateEmailAndPasswordByUserId(ctx context.Context, arg UpdateEmailAndPasswordByUserIdParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateEmailAndPasswordByUserId, arg.Newemail, arg.Newhashedpassword, arg.Userid)
+// updates a user's email, password and profile fields; unchanged fields are passed through with their current values
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUser,
+		arg.Email,
+		arg.HashedPassword,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Bio,
+		arg.AvatarMediaID,
+		arg.IsPrivate,
+		arg.ID,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -102,6 +466,12 @@ func (q *Queries) UpdateEmailAndPasswordByUserId(ctx context.Context, arg Update
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.DeletedAt,
+		&i.IsPrivate,
 	)
 	return i, err
 }
@@ -112,7 +482,7 @@ SET
     updated_at = NOW(),
     is_chirpy_red = TRUE
 WHERE
-    id = $1 RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+    id = $1 RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_media_id, deleted_at, is_private
 `
 
 // upgrades a user to chirpy red based on their ID by modifying the is_chirpy_field to true.
@@ -126,6 +496,12 @@ func (q *Queries) UpgradeUserToChirpyRedById(ctx context.Context, userid uuid.UU
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.DeletedAt,
+		&i.IsPrivate,
 	)
 	return i, err
 }
@@ -0,0 +1,196 @@
+// Package linkpreview fetches the OpenGraph and Twitter card metadata of web pages linked from chirps, so they can be shown as preview cards.
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// A Preview is the card shown for a link. Any field other than URL may be empty.
+type Preview struct {
+	URL         string // the page's canonical URL, after following redirects
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+var (
+	// ErrBlockedAddress is returned for links that resolve to loopback, private or otherwise internal addresses.
+	ErrBlockedAddress = errors.New("address is not allowed")
+	// ErrNotHTML is returned for links to anything other than an HTML page.
+	ErrNotHTML = errors.New("not an html page")
+	// ErrNoMetadata is returned for pages without a title or description to show.
+	ErrNoMetadata = errors.New("page has no preview metadata")
+)
+
+// default limits applied to every fetch
+const (
+	defaultTimeout      = 5 * time.Second
+	defaultMaxBodyBytes = 512 << 10
+	defaultMaxRedirects = 3
+	maxHeaderBytes      = 64 << 10
+	userAgent           = "ChirpyBot/1.0 (+link previews)"
+)
+
+// Options configures a Fetcher; zero values use the defaults.
+type Options struct {
+	Timeout      time.Duration // limit on the whole fetch, redirects included
+	MaxBodyBytes int64         // only this much of a page is read; metadata further down is ignored
+	MaxRedirects int
+	// AllowPrivateNetworks disables the address checks, so tests can fetch from an httptest server on localhost.
+	// It must never be set in production, where it would let chirps probe the internal network.
+	AllowPrivateNetworks bool
+}
+
+// A Fetcher retrieves link previews. It is safe for concurrent use.
+type Fetcher struct {
+	client       *http.Client
+	maxBodyBytes int64
+}
+
+// NewFetcher creates a Fetcher. Unless opts.AllowPrivateNetworks is set, it refuses to connect to any address that isn't publicly routable.
+// The address is checked when connecting, after DNS resolution and for every redirect, so a hostname can't be pointed at an internal address to get around it.
+func NewFetcher(opts Options) *Fetcher {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	if opts.MaxRedirects <= 0 {
+		opts.MaxRedirects = defaultMaxRedirects
+	}
+
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !IsPublicAddress(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrBlockedAddress, addrPort.Addr())
+			}
+			return nil
+		}
+	}
+
+	transport := &http.Transport{
+		Proxy:                  nil, // a proxy would make the connection, bypassing the address checks
+		DialContext:            dialer.DialContext,
+		TLSHandshakeTimeout:    opts.Timeout,
+		ResponseHeaderTimeout:  opts.Timeout,
+		MaxResponseHeaderBytes: maxHeaderBytes,
+		MaxIdleConns:           10,
+		IdleConnTimeout:        30 * time.Second,
+	}
+
+	return &Fetcher{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   opts.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= opts.MaxRedirects {
+					return fmt.Errorf("stopped after %d redirects", opts.MaxRedirects)
+				}
+				return checkURL(req.URL)
+			},
+		},
+		maxBodyBytes: opts.MaxBodyBytes,
+	}
+}
+
+// Fetch retrieves the preview of the page at rawURL.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (Preview, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Preview{}, err
+	}
+	if err := checkURL(u); err != nil {
+		return Preview{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Preview{}, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return Preview{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Preview{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return Preview{}, fmt.Errorf("%w: %s", ErrNotHTML, mediaType)
+	}
+
+	preview, err := parseMetadata(io.LimitReader(resp.Body, f.maxBodyBytes), resp.Request.URL)
+	if err != nil {
+		return Preview{}, err
+	}
+	if preview.Title == "" && preview.Description == "" {
+		return Preview{}, ErrNoMetadata
+	}
+	return preview, nil
+}
+
+// checkURL only lets through plain http(s) links, without credentials.
+func checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if u.User != nil {
+		return errors.New("links with credentials are not fetched")
+	}
+	if u.Hostname() == "" {
+		return errors.New("link has no host")
+	}
+	return nil
+}
+
+// ranges that IsGlobalUnicast and IsPrivate don't cover, but which still aren't reachable on the public internet
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, which can map onto private IPv4 addresses
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+}
+
+// IsPublicAddress reports whether addr is a publicly routable unicast address,
+// rejecting loopback, private, link-local, multicast and reserved ranges (including IPv4 addresses mapped into IPv6).
+func IsPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
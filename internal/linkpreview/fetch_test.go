@@ -0,0 +1,148 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+const articlePage = `<!DOCTYPE html>
+<html><head>
+<title>Fallback title</title>
+<meta property="og:title" content="  Gophers   everywhere ">
+<meta property="og:description" content="A story about gophers.">
+<meta property="og:image" content="/images/gopher.png">
+<meta property="og:site_name" content="Go News">
+<meta name="twitter:title" content="Twitter title">
+</head><body><meta property="og:title" content="ignored"></body></html>`
+
+func newTestFetcher(opts Options) *Fetcher {
+	opts.AllowPrivateNetworks = true
+	return NewFetcher(opts)
+}
+
+func TestFetchOpenGraph(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(articlePage))
+	})
+	mux.Handle("/short", http.RedirectHandler("/article", http.StatusFound))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	preview, err := newTestFetcher(Options{}).Fetch(context.Background(), server.URL+"/short")
+	if err != nil {
+		t.Fatalf("Fetch: %s", err)
+	}
+	expected := Preview{
+		URL:         server.URL + "/article",
+		Title:       "Gophers everywhere",
+		Description: "A story about gophers.",
+		ImageURL:    server.URL + "/images/gopher.png",
+		SiteName:    "Go News",
+	}
+	if preview != expected {
+		t.Errorf("Fetch = %+v, expected %+v", preview, expected)
+	}
+}
+
+func TestFetchFallsBackToTwitterAndTitle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Plain &amp; simple</title>
+<meta name="description" content="plain description">
+<meta name="twitter:image" content="https://cdn.example.com/card.jpg">
+<meta property="og:image" content="javascript:alert(1)">
+</head></html>`))
+	}))
+	defer server.Close()
+
+	preview, err := newTestFetcher(Options{}).Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch: %s", err)
+	}
+	if preview.Title != "Plain & simple" || preview.Description != "plain description" {
+		t.Errorf("unexpected title/description: %+v", preview)
+	}
+	// og:image wins, but isn't an http(s) link, so no image is used
+	if preview.ImageURL != "" {
+		t.Errorf("expected no image, got %q", preview.ImageURL)
+	}
+}
+
+func TestFetchBlocksPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a server on a private address")
+	}))
+	defer server.Close()
+
+	_, err := NewFetcher(Options{}).Fetch(context.Background(), server.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("expected ErrBlockedAddress, got %v", err)
+	}
+}
+
+func TestFetchLimits(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	mux.HandleFunc("/big", func(w http.ResponseWriter, r *http.Request) {
+		// the metadata comes after more padding than the fetcher reads
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><head><!--" + strings.Repeat("x", 4096) + "-->" + `<meta property="og:title" content="too far down"></head></html>`))
+	})
+	mux.HandleFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := newTestFetcher(Options{Timeout: 100 * time.Millisecond, MaxBodyBytes: 1024})
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/slow"); err == nil {
+		t.Error("expected slow page to time out")
+	}
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/big"); !errors.Is(err, ErrNoMetadata) {
+		t.Errorf("expected ErrNoMetadata for oversized page, got %v", err)
+	}
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/image"); !errors.Is(err, ErrNotHTML) {
+		t.Errorf("expected ErrNotHTML, got %v", err)
+	}
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/loop"); err == nil {
+		t.Error("expected redirect loop to fail")
+	}
+	if _, err := fetcher.Fetch(context.Background(), "ftp://example.com/file"); err == nil {
+		t.Error("expected non-http link to be rejected")
+	}
+}
+
+func TestIsPublicAddress(t *testing.T) {
+	public := []string{"93.184.215.14", "2606:2800:21f:cb07:6820:80da:af6b:8b2c", "8.8.8.8"}
+	private := []string{
+		"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "0.0.0.0",
+		"::1", "fe80::1", "fc00::1", "::ffff:127.0.0.1", "::ffff:10.0.0.1", "64:ff9b::a00:1", "224.0.0.1",
+	}
+
+	for _, s := range public {
+		if !IsPublicAddress(netip.MustParseAddr(s)) {
+			t.Errorf("expected %s to be public", s)
+		}
+	}
+	for _, s := range private {
+		if IsPublicAddress(netip.MustParseAddr(s)) {
+			t.Errorf("expected %s to be blocked", s)
+		}
+	}
+}
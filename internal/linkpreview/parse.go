@@ -0,0 +1,132 @@
+package linkpreview
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// longer values are truncated, so a page can't blow up the size of every chirp that links to it
+const (
+	maxTitleLength       = 200
+	maxDescriptionLength = 500
+	maxSiteNameLength    = 100
+	maxImageURLLength    = 2048
+)
+
+// parseMetadata reads the <head> of an HTML page, preferring OpenGraph tags, then Twitter card tags, then the plain <title> and description.
+// base is the URL the page was served from, used to resolve relative image and canonical URLs.
+func parseMetadata(r io.Reader, base *url.URL) (Preview, error) {
+	meta := make(map[string]string)
+	var title string
+	inTitle := false
+
+	z := html.NewTokenizer(r)
+tokens:
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			// a page cut short by the size limit ends here too; whatever was read so far is used
+			if err := z.Err(); !errors.Is(err, io.EOF) {
+				return Preview{}, err
+			}
+			break tokens
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Body:
+				break tokens // metadata lives in the head
+			case atom.Title:
+				inTitle = title == ""
+			case atom.Meta:
+				if hasAttr {
+					key, content := metaAttributes(z)
+					if _, seen := meta[key]; key != "" && !seen {
+						meta[key] = content
+					}
+				}
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Head:
+				break tokens
+			case atom.Title:
+				inTitle = false
+			}
+		case html.TextToken:
+			if inTitle {
+				title += string(z.Text())
+			}
+		}
+	}
+
+	preview := Preview{
+		URL:         base.String(),
+		Title:       clean(firstOf(meta["og:title"], meta["twitter:title"], title), maxTitleLength),
+		Description: clean(firstOf(meta["og:description"], meta["twitter:description"], meta["description"]), maxDescriptionLength),
+		SiteName:    clean(meta["og:site_name"], maxSiteNameLength),
+	}
+	if canonical := resolveURL(base, meta["og:url"]); canonical != "" {
+		preview.URL = canonical
+	}
+	if image := resolveURL(base, firstOf(meta["og:image"], meta["og:image:url"], meta["twitter:image"], meta["twitter:image:src"])); len(image) <= maxImageURLLength {
+		preview.ImageURL = image
+	}
+	return preview, nil
+}
+
+// metaAttributes returns the lowercased property (or name) and the content of a <meta> tag.
+func metaAttributes(z *html.Tokenizer) (key, content string) {
+	for {
+		name, value, more := z.TagAttr()
+		switch string(name) {
+		case "property", "name":
+			if key == "" {
+				key = strings.ToLower(strings.TrimSpace(string(value)))
+			}
+		case "content":
+			content = string(value)
+		}
+		if !more {
+			return key, content
+		}
+	}
+}
+
+// resolveURL resolves a possibly relative link against base, returning "" unless the result is an http(s) URL.
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return u.String()
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// clean collapses whitespace, drops invalid UTF-8 and truncates s to at most maxRunes characters.
+func clean(s string, maxRunes int) string {
+	s = strings.Join(strings.Fields(strings.ToValidUTF8(s, "")), " ")
+	if utf8.RuneCountInString(s) <= maxRunes {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:maxRunes-1])) + "…"
+}
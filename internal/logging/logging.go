@@ -0,0 +1,86 @@
+// Package logging sets up structured JSON logging, and gives every HTTP request an id and a logger that carries it.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// New creates a logger that writes JSON lines at the provided level and above.
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// ParseLevel parses a level name such as "debug", "info", "warn" or "error", case-insensitively. An empty name means info.
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q", s)
+	}
+	return level, nil
+}
+
+// requestInfo is what the middleware knows about a request, shared between the request's context and its response writer.
+// The user id is only known once a handler has authenticated the request, so it is filled in while the request is handled.
+type requestInfo struct {
+	id     string
+	route  string
+	base   *slog.Logger // the logger every request logger is derived from
+	mu     sync.Mutex
+	userID string
+}
+
+// logger returns a logger that adds the request's id, route and (once known) user id to every line.
+func (info *requestInfo) logger() *slog.Logger {
+	logger := info.base.With("request_id", info.id, "route", info.route)
+	if userID := info.getUserID(); userID != "" {
+		logger = logger.With("user_id", userID)
+	}
+	return logger
+}
+
+func (info *requestInfo) getUserID() string {
+	info.mu.Lock()
+	defer info.mu.Unlock()
+	return info.userID
+}
+
+type contextKey struct{}
+
+func infoFromContext(ctx context.Context) *requestInfo {
+	info, _ := ctx.Value(contextKey{}).(*requestInfo)
+	return info
+}
+
+// FromContext returns the logger for the request a context belongs to, or the default logger outside of a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if info := infoFromContext(ctx); info != nil {
+		return info.logger()
+	}
+	return slog.Default()
+}
+
+// RequestID returns the id of the request a context belongs to, or "" outside of a request.
+func RequestID(ctx context.Context) string {
+	if info := infoFromContext(ctx); info != nil {
+		return info.id
+	}
+	return ""
+}
+
+// SetUserID records the authenticated user making a request, so the request's later log lines and its access log include them.
+// It does nothing outside of a request.
+func SetUserID(ctx context.Context, userID string) {
+	if info := infoFromContext(ctx); info != nil {
+		info.mu.Lock()
+		info.userID = userID
+		info.mu.Unlock()
+	}
+}
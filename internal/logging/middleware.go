@@ -0,0 +1,140 @@
+package logging
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries a request's id. Clients may send one to correlate their own logs; it is always echoed in the response.
+const RequestIDHeader = "X-Request-ID"
+
+// request ids sent by clients are only used if they are at most this long
+const maxRequestIDLength = 128
+
+// A router can report which of its routes matches a request without serving it, like http.ServeMux.
+type router interface {
+	Handler(r *http.Request) (h http.Handler, pattern string)
+}
+
+// Middleware gives every request an id, taken from a valid X-Request-ID header or generated, and echoes it in the response.
+// Handlers get a logger carrying the request id and route with FromContext, and every request is logged once it completes,
+// with its status code, size and latency. When next is an http.ServeMux the route is the pattern that matched the request.
+func Middleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		info := &requestInfo{id: id, base: logger}
+		if router, ok := next.(router); ok {
+			_, info.route = router.Handler(r)
+		}
+		rw := &responseWriter{ResponseWriter: w, info: info, status: http.StatusOK}
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), contextKey{}, info)))
+
+		level := slog.LevelInfo
+		if rw.status >= 500 {
+			level = slog.LevelError
+		}
+		info.logger().LogAttrs(r.Context(), level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rw.status),
+			slog.Int64("bytes", rw.bytes),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.String("user_agent", r.UserAgent()),
+		)
+	})
+}
+
+// validRequestID reports whether a client-provided request id is safe to log and echo: non-empty, short, and printable ASCII without spaces.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// responseWriter records the status code and size of a response for the access log.
+// It keeps the streaming endpoints working: Flush passes through for Server-Sent Events, and Hijack for WebSocket upgrades.
+type responseWriter struct {
+	http.ResponseWriter
+	info        *requestInfo
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.status = code
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// Flush sends any buffered data to the client, if the underlying response writer supports it.
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		rw.wroteHeader = true
+		flusher.Flush()
+	}
+}
+
+// Hijack lets the caller take over the connection, if the underlying response writer supports it. The access log records a 101 status.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	conn, brw, err := hijacker.Hijack()
+	if err == nil {
+		rw.status = http.StatusSwitchingProtocols
+		rw.wroteHeader = true
+	}
+	return conn, brw, err
+}
+
+// Unwrap exposes the underlying response writer to http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// FromResponseWriter returns the logger for the request a response writer belongs to, or the default logger if it wasn't wrapped by Middleware.
+// It lets helpers that are only given the response writer, such as error responders, log with the request's id.
+func FromResponseWriter(w http.ResponseWriter) *slog.Logger {
+	for {
+		switch rw := w.(type) {
+		case *responseWriter:
+			return rw.info.logger()
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return slog.Default()
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// readLogLines decodes the JSON lines written by a test logger.
+func readLogLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, raw := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if raw == "" {
+			continue
+		}
+		line := map[string]any{}
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatalf("log line %q is not JSON: %s", raw, err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestMiddlewareRequestIDs(t *testing.T) {
+	var seen string
+	handler := Middleware(New(&bytes.Buffer{}, slog.LevelInfo), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+	}))
+
+	cases := []struct {
+		header    string
+		propagate bool
+	}{
+		{header: "", propagate: false},
+		{header: "abc-123", propagate: true},
+		{header: "has spaces", propagate: false},
+		{header: strings.Repeat("x", maxRequestIDLength+1), propagate: false},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if c.header != "" {
+			req.Header.Set(RequestIDHeader, c.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		echoed := rec.Header().Get(RequestIDHeader)
+		if echoed == "" || echoed != seen {
+			t.Errorf("header %q: echoed id %q, handler saw %q", c.header, echoed, seen)
+		}
+		if (echoed == c.header) != c.propagate {
+			t.Errorf("header %q: echoed id %q, expected propagate=%t", c.header, echoed, c.propagate)
+		}
+	}
+}
+
+func TestMiddlewareAccessLog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /chirps/{chirpID}", func(w http.ResponseWriter, r *http.Request) {
+		SetUserID(r.Context(), "user-1")
+		FromContext(r.Context()).Info("handling")
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/chirps/42", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	Middleware(New(buf, slog.LevelInfo), mux).ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := readLogLines(t, buf)
+	if len(lines) != 2 {
+		t.Fatalf("expected a handler line and an access line, got %v", lines)
+	}
+	for _, line := range lines {
+		if line["request_id"] != "req-1" || line["route"] != "GET /chirps/{chirpID}" || line["user_id"] != "user-1" {
+			t.Errorf("log line is missing request attributes: %v", line)
+		}
+	}
+	access := lines[1]
+	if access["msg"] != "request" || access["status"] != float64(http.StatusTeapot) || access["bytes"] != float64(15) || access["path"] != "/chirps/42" {
+		t.Errorf("unexpected access log line: %v", access)
+	}
+	if _, ok := access["latency_ms"].(float64); !ok {
+		t.Errorf("access log line has no latency: %v", access)
+	}
+}
+
+func TestMiddlewareLevels(t *testing.T) {
+	buf := &bytes.Buffer{}
+	handler := Middleware(New(buf, slog.LevelWarn), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	lines := readLogLines(t, buf)
+	if len(lines) != 1 || lines[0]["level"] != "ERROR" || lines[0]["path"] != "/fail" {
+		t.Errorf("expected only the failed request to be logged at warn level, got %v", lines)
+	}
+
+	for name, expected := range map[string]slog.Level{"": slog.LevelInfo, "debug": slog.LevelDebug, "WARN": slog.LevelWarn, "error": slog.LevelError} {
+		if level, err := ParseLevel(name); err != nil || level != expected {
+			t.Errorf("ParseLevel(%q) = %s, %v; expected %s", name, level, err, expected)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("ParseLevel accepted an unknown level")
+	}
+}
+
+// hijackableRecorder is a ResponseRecorder whose connection can be hijacked, like a real server's.
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (h *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	return nil, nil, nil
+}
+
+func TestMiddlewareKeepsStreamingInterfaces(t *testing.T) {
+	buf := &bytes.Buffer{}
+	rec := &hijackableRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler := Middleware(New(buf, slog.LevelInfo), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("wrapped response writer is not a Flusher")
+		}
+		w.Write([]byte("data: hello\n\n"))
+		flusher.Flush()
+
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("ResponseController could not flush: %s", err)
+		}
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			t.Fatal("wrapped response writer is not a Hijacker")
+		}
+		if _, _, err := hijacker.Hijack(); err != nil {
+			t.Errorf("Hijack: %s", err)
+		}
+	}))
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	if !rec.Flushed || !rec.hijacked {
+		t.Errorf("flushed = %t, hijacked = %t; expected both to reach the underlying writer", rec.Flushed, rec.hijacked)
+	}
+	if lines := readLogLines(t, buf); len(lines) != 1 || lines[0]["status"] != float64(http.StatusSwitchingProtocols) {
+		t.Errorf("expected a 101 access log line, got %v", lines)
+	}
+}
+
+func TestFromResponseWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	handler := Middleware(New(buf, slog.LevelInfo), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromResponseWriter(w).Info("from writer")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "req-2")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := readLogLines(t, buf)
+	if len(lines) != 2 || lines[0]["msg"] != "from writer" || lines[0]["request_id"] != "req-2" {
+		t.Errorf("expected the writer's logger to carry the request id, got %v", lines)
+	}
+	if FromResponseWriter(httptest.NewRecorder()) != slog.Default() {
+		t.Error("expected the default logger for an unwrapped response writer")
+	}
+}
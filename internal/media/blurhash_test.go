@@ -0,0 +1,31 @@
+package media
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"testing"
+)
+
+func TestBlurhashSolidBlack(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+
+	// size flag "L" (4x3 components), no AC energy ("0"), black DC ("0000"), and eleven zero AC components ("fQ")
+	expected := "L00000" + strings.Repeat("fQ", blurhashXComponents*blurhashYComponents-1)
+	if hash := Blurhash(img); hash != expected {
+		t.Errorf("expected blurhash %q for a solid black image, got %q", expected, hash)
+	}
+}
+
+func TestBlurhashDistinguishesImages(t *testing.T) {
+	left := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	right := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	draw.Draw(left, image.Rect(0, 0, 8, 16), &image.Uniform{color.White}, image.Point{}, draw.Src)
+	draw.Draw(right, image.Rect(8, 0, 16, 16), &image.Uniform{color.White}, image.Point{}, draw.Src)
+
+	if Blurhash(left) == Blurhash(right) {
+		t.Errorf("expected mirrored images to have different blurhashes")
+	}
+}
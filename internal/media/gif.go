@@ -0,0 +1,65 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errMalformedGIF = errors.New("malformed gif")
+
+// gifFrames counts the frames of a GIF and the total number of pixels they decode to, without decoding any of them.
+// image.DecodeConfig only reads the logical screen, but gif.DecodeAll allocates every frame, and LZW compresses a
+// uniform frame so well that a small upload can expand to gigabytes; the counts let Inspect reject those first.
+func gifFrames(data []byte) (frames int, pixels int64, err error) {
+	// header (6 bytes) and logical screen descriptor (7 bytes)
+	if len(data) < 13 {
+		return 0, 0, errMalformedGIF
+	}
+	i := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		i += 3 << (flags&0x07 + 1) // global color table
+	}
+
+	for {
+		if i >= len(data) {
+			return 0, 0, errMalformedGIF
+		}
+		switch data[i] {
+		case 0x21: // extension: label, then data sub-blocks
+			if i += 2; i > len(data) {
+				return 0, 0, errMalformedGIF
+			}
+		case 0x2C: // image descriptor: position, size and flags, then an optional local color table and the LZW minimum code size
+			if i+10 > len(data) {
+				return 0, 0, errMalformedGIF
+			}
+			width := int64(binary.LittleEndian.Uint16(data[i+5 : i+7]))
+			height := int64(binary.LittleEndian.Uint16(data[i+7 : i+9]))
+			frames++
+			pixels += width * height
+
+			flags := data[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+			i++ // LZW minimum code size
+		case 0x3B: // trailer
+			return frames, pixels, nil
+		default:
+			return 0, 0, errMalformedGIF
+		}
+
+		// skip the data sub-blocks, each prefixed with its length and ended by an empty block
+		for {
+			if i >= len(data) {
+				return 0, 0, errMalformedGIF
+			}
+			size := int(data[i])
+			i += 1 + size
+			if size == 0 {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"net/http"
+
+	// register decoders for image.DecodeConfig
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+// Limits applied to uploaded images.
+const (
+	MaxUploadBytes = 5 << 20     // 5 MiB
+	MaxDimension   = 8192        // pixels, in either direction
+	MaxGIFFrames   = 50          // frames in an animated gif
+	MaxGIFPixels   = 100_000_000 // pixels across all frames of an animated gif
+)
+
+// allowed content types, mapped to the file extension used in blob keys
+var allowedContentTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// ErrUnsupportedType is returned by Inspect for content that is not an allowed image format.
+var ErrUnsupportedType = errors.New("unsupported media type; expected a png, jpeg, gif or webp image")
+
+// Info describes an uploaded image.
+type Info struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// Inspect sniffs the content type of an upload from its bytes (ignoring whatever the client claimed), and checks that it is a well-formed image within the allowed dimensions.
+// Animated gifs are also checked against the frame and total pixel limits, since every frame is decoded when they are processed.
+func Inspect(data []byte) (Info, error) {
+	contentType := http.DetectContentType(data)
+	ext, ok := allowedContentTypes[contentType]
+	if !ok {
+		return Info{}, ErrUnsupportedType
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Info{}, fmt.Errorf("could not read image: %w", err)
+	}
+	// the decoder must agree with the sniffed type, e.g. a png signature followed by garbage is rejected
+	if "image/"+format != contentType {
+		return Info{}, ErrUnsupportedType
+	}
+	if cfg.Width < 1 || cfg.Height < 1 || cfg.Width > MaxDimension || cfg.Height > MaxDimension {
+		return Info{}, fmt.Errorf("image must be between 1x1 and %dx%d pixels, got %dx%d", MaxDimension, MaxDimension, cfg.Width, cfg.Height)
+	}
+
+	if contentType == "image/gif" {
+		frames, pixels, err := gifFrames(data)
+		if err != nil {
+			return Info{}, fmt.Errorf("could not read image: %w", err)
+		}
+		if frames > MaxGIFFrames {
+			return Info{}, fmt.Errorf("gif must have at most %d frames, got %d", MaxGIFFrames, frames)
+		}
+		if pixels > MaxGIFPixels {
+			return Info{}, fmt.Errorf("gif must have at most %d pixels across all frames, got %d", MaxGIFPixels, pixels)
+		}
+	}
+
+	return Info{
+		ContentType: contentType,
+		Extension:   ext,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+	}, nil
+}
@@ -0,0 +1,41 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("could not encode test png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestInspect(t *testing.T) {
+	info, err := Inspect(testPNG(t, 30, 20))
+	if err != nil {
+		t.Fatalf("expected a valid png to pass inspection: %v", err)
+	}
+	if info.ContentType != "image/png" || info.Extension != ".png" || info.Width != 30 || info.Height != 20 {
+		t.Errorf("unexpected info for png: %+v", info)
+	}
+
+	if _, err := Inspect([]byte("<html><script>alert(1)</script></html>")); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("expected html to be rejected as unsupported, got %v", err)
+	}
+
+	truncated := testPNG(t, 30, 20)[:20]
+	if _, err := Inspect(truncated); err == nil {
+		t.Errorf("expected a truncated png to be rejected")
+	}
+
+	if _, err := Inspect(testPNG(t, MaxDimension+1, 1)); err == nil {
+		t.Errorf("expected an oversized png to be rejected")
+	}
+}
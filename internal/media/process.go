@@ -0,0 +1,158 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+)
+
+// ThumbnailSizes are the thumbnails generated for every image, each fitting within a square of MaxDimension pixels.
+// Images are never scaled up, so a thumbnail of a small image has the image's own size.
+var ThumbnailSizes = []struct {
+	Name         string
+	MaxDimension int
+}{
+	{Name: "small", MaxDimension: 150},
+	{Name: "medium", MaxDimension: 600},
+	{Name: "large", MaxDimension: 1200},
+}
+
+// images are downscaled to this size before computing their blurhash
+const blurhashSourceDimension = 32
+
+const jpegQuality = 85
+
+// An Encoded image is the output of Process, ready to be stored.
+type Encoded struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// A Thumbnail is a downscaled copy of a processed image.
+type Thumbnail struct {
+	Name string
+	Encoded
+}
+
+// Processed is the result of running an uploaded image through Process.
+type Processed struct {
+	// Original is the uploaded image re-encoded without any metadata.
+	Original   Encoded
+	Thumbnails []Thumbnail
+	Blurhash   string
+}
+
+// Process prepares an uploaded image for serving:
+//
+//   - the image is decoded and re-encoded from its pixels, which strips EXIF (including GPS location) and any other embedded metadata or trailing data;
+//     JPEG orientation is applied to the pixels first, so photos stay upright
+//   - JPEG, PNG and GIF (including animations) keep their format; WebP is re-encoded as PNG
+//   - a thumbnail is generated for each of ThumbnailSizes, as JPEG for opaque images and PNG otherwise
+//   - a blurhash placeholder is computed
+func Process(data []byte) (Processed, error) {
+	info, err := Inspect(data)
+	if err != nil {
+		return Processed{}, err
+	}
+
+	var img image.Image
+	var original Encoded
+	switch info.ContentType {
+	case "image/gif":
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return Processed{}, fmt.Errorf("could not decode gif: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := gif.EncodeAll(&buf, anim); err != nil {
+			return Processed{}, fmt.Errorf("could not encode gif: %w", err)
+		}
+		img = anim.Image[0]
+		original = Encoded{ContentType: "image/gif", Extension: ".gif", Data: buf.Bytes()}
+	default:
+		img, _, err = image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Processed{}, fmt.Errorf("could not decode image: %w", err)
+		}
+		if info.ContentType == "image/jpeg" {
+			img = applyOrientation(img, jpegOrientation(data))
+			original, err = encodeJPEG(img)
+		} else {
+			original, err = encodePNG(img)
+		}
+		if err != nil {
+			return Processed{}, err
+		}
+	}
+	original.Width, original.Height = img.Bounds().Dx(), img.Bounds().Dy()
+
+	thumbnails := make([]Thumbnail, 0, len(ThumbnailSizes))
+	for _, size := range ThumbnailSizes {
+		scaled := scaleToFit(img, size.MaxDimension)
+		var encoded Encoded
+		if isOpaque(scaled) {
+			encoded, err = encodeJPEG(scaled)
+		} else {
+			encoded, err = encodePNG(scaled)
+		}
+		if err != nil {
+			return Processed{}, err
+		}
+		encoded.Width, encoded.Height = scaled.Bounds().Dx(), scaled.Bounds().Dy()
+		thumbnails = append(thumbnails, Thumbnail{Name: size.Name, Encoded: encoded})
+	}
+
+	return Processed{
+		Original:   original,
+		Thumbnails: thumbnails,
+		Blurhash:   Blurhash(scaleToFit(img, blurhashSourceDimension)),
+	}, nil
+}
+
+// scaleToFit downscales img to fit within a maxDimension square, preserving its aspect ratio.
+func scaleToFit(img image.Image, maxDimension int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > maxDimension || h > maxDimension {
+		if w >= h {
+			w, h = maxDimension, max(1, h*maxDimension/w)
+		} else {
+			w, h = max(1, w*maxDimension/h), maxDimension
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+func encodeJPEG(img image.Image) (Encoded, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return Encoded{}, fmt.Errorf("could not encode jpeg: %w", err)
+	}
+	return Encoded{ContentType: "image/jpeg", Extension: ".jpg", Data: buf.Bytes()}, nil
+}
+
+func encodePNG(img image.Image) (Encoded, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return Encoded{}, fmt.Errorf("could not encode png: %w", err)
+	}
+	return Encoded{ContentType: "image/png", Extension: ".png", Data: buf.Bytes()}, nil
+}
@@ -0,0 +1,148 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"testing"
+)
+
+// withExif inserts an EXIF segment recording orientation and a GPS IFD pointer straight after a JPEG's SOI marker.
+func withExif(t *testing.T, jpegData []byte, orientation uint16) []byte {
+	t.Helper()
+
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8)) // IFD0 offset
+	binary.Write(&tiff, binary.BigEndian, uint16(2)) // entry count
+	// orientation: SHORT, count 1
+	binary.Write(&tiff, binary.BigEndian, []uint16{exifOrientationTag, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	// GPS IFD pointer: LONG, count 1
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x8825, 4})
+	binary.Write(&tiff, binary.BigEndian, []uint32{1, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0)) // no next IFD
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	var out bytes.Buffer
+	out.Write(jpegData[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	out.Write(jpegData[2:])
+	return out.Bytes()
+}
+
+func TestProcessStripsExifAndAppliesOrientation(t *testing.T) {
+	// a 40x20 landscape photo, taken with the camera rotated (orientation 6 = rotate 90 degrees clockwise)
+	src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			src.Set(x, y, color.RGBA{R: uint8(x * 6), G: uint8(y * 12), B: 100, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, nil); err != nil {
+		t.Fatalf("could not encode test jpeg: %v", err)
+	}
+	upload := withExif(t, buf.Bytes(), 6)
+	if jpegOrientation(upload) != 6 {
+		t.Fatalf("expected test upload to carry orientation 6")
+	}
+
+	processed, err := Process(upload)
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	if bytes.Contains(processed.Original.Data, []byte("Exif")) {
+		t.Errorf("expected EXIF metadata to be stripped from the processed image")
+	}
+	if processed.Original.ContentType != "image/jpeg" {
+		t.Errorf("expected jpeg to stay jpeg, got %s", processed.Original.ContentType)
+	}
+	if processed.Original.Width != 20 || processed.Original.Height != 40 {
+		t.Errorf("expected orientation to rotate the image to 20x40, got %dx%d", processed.Original.Width, processed.Original.Height)
+	}
+
+	if len(processed.Thumbnails) != len(ThumbnailSizes) {
+		t.Fatalf("expected %d thumbnails, got %d", len(ThumbnailSizes), len(processed.Thumbnails))
+	}
+	for _, thumb := range processed.Thumbnails {
+		// the image is smaller than every thumbnail size, and is never scaled up
+		if thumb.Width != 20 || thumb.Height != 40 {
+			t.Errorf("expected %s thumbnail to be 20x40, got %dx%d", thumb.Name, thumb.Width, thumb.Height)
+		}
+	}
+	if processed.Blurhash == "" {
+		t.Errorf("expected a blurhash")
+	}
+}
+
+func TestScaleToFit(t *testing.T) {
+	scaled := scaleToFit(image.NewRGBA(image.Rect(0, 0, 1000, 250)), 150)
+	if b := scaled.Bounds(); b.Dx() != 150 || b.Dy() != 37 {
+		t.Errorf("expected 1000x250 to scale to 150x37, got %dx%d", b.Dx(), b.Dy())
+	}
+}
+
+// testGIF encodes an animation of identical, uniformly colored frames.
+func testGIF(t *testing.T, frames, width, height int) []byte {
+	t.Helper()
+	anim := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9))
+		anim.Delay = append(anim.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatalf("could not encode test gif: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessGIFFrameLimits(t *testing.T) {
+	processed, err := Process(testGIF(t, 3, 40, 20))
+	if err != nil {
+		t.Fatalf("expected a small animation to be processed: %v", err)
+	}
+	if processed.Original.ContentType != "image/gif" || processed.Original.Width != 40 || processed.Original.Height != 20 {
+		t.Errorf("unexpected original for gif: %s %dx%d", processed.Original.ContentType, processed.Original.Width, processed.Original.Height)
+	}
+
+	if _, err := Process(testGIF(t, MaxGIFFrames+1, 10, 10)); err == nil {
+		t.Errorf("expected a gif with too many frames to be rejected")
+	}
+
+	// a decompression bomb: a uniform 2000x2000 frame compresses to a few kilobytes, so repeating it
+	// gives a small file that would decode to more pixels than allowed (while staying under the frame limit)
+	single := testGIF(t, 1, 2000, 2000)
+	frames, pixels, err := gifFrames(single)
+	if err != nil || frames != 1 || pixels != 2000*2000 {
+		t.Fatalf("gifFrames(single frame) = %d, %d, %v", frames, pixels, err)
+	}
+	// the frame starts after the header and logical screen descriptor (EncodeAll gives it a local color table rather than a global one)
+	// and runs up to the trailer
+	start := 13
+	frame := single[start : len(single)-1]
+	bomb := append([]byte{}, single[:start]...)
+	for i := 0; i < MaxGIFPixels/(2000*2000)+1; i++ {
+		bomb = append(bomb, frame...)
+	}
+	bomb = append(bomb, 0x3B)
+	if len(bomb) > MaxUploadBytes {
+		t.Fatalf("test bomb is %d bytes, larger than an upload", len(bomb))
+	}
+	if frames, pixels, err := gifFrames(bomb); err != nil || frames > MaxGIFFrames || pixels <= MaxGIFPixels {
+		t.Fatalf("gifFrames(bomb) = %d, %d, %v; expected too many pixels in too few frames", frames, pixels, err)
+	}
+	if _, err := Process(bomb); err == nil {
+		t.Errorf("expected a gif decoding to too many pixels to be rejected")
+	}
+}
@@ -0,0 +1,79 @@
+package spam
+
+import (
+	"context"
+	"time"
+
+	"github.com/rickNoise/chirpy/internal/chirptext"
+)
+
+// LinkDensity scores chirps by how much of their length is taken up by links, with every link after the first adding to the score.
+// A chirp that is nothing but a link scores 1.
+type LinkDensity struct{}
+
+func (LinkDensity) Name() string { return "link_density" }
+
+func (LinkDensity) Score(_ context.Context, s Subject) (float64, error) {
+	links := len(chirptext.ParseURLs(s.Body))
+	length := chirptext.Length(s.Body)
+	if links == 0 || length == 0 {
+		return 0, nil
+	}
+	density := float64(links*chirptext.URLWeight) / float64(length)
+	return density + 0.25*float64(links-1), nil
+}
+
+// AccountAge scores chirps by how new their author's account is: 1 for accounts younger than Min, falling to 0 for accounts older than Max.
+type AccountAge struct {
+	Min time.Duration
+	Max time.Duration
+	Now func() time.Time // optional; defaults to time.Now
+}
+
+func (AccountAge) Name() string { return "account_age" }
+
+func (a AccountAge) Score(_ context.Context, s Subject) (float64, error) {
+	if s.AccountCreatedAt.IsZero() {
+		return 0, nil
+	}
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	age := now().Sub(s.AccountCreatedAt)
+	return 1 - ramp(float64(age), float64(a.Min), float64(a.Max)), nil
+}
+
+// A CountFunc counts recent activity related to a subject, such as chirps with the same body or chirps by the same user.
+type CountFunc func(ctx context.Context, s Subject) (int, error)
+
+// RecentCount scores a subject by counting recent activity: 0 at or below Low, rising to 1 at High.
+// It is the building block for the duplicate-body, posting-velocity and signup-velocity signals, whose counts come from the database.
+type RecentCount struct {
+	SignalName string
+	Count      CountFunc
+	Low        int
+	High       int
+}
+
+func (c RecentCount) Name() string { return c.SignalName }
+
+func (c RecentCount) Score(ctx context.Context, s Subject) (float64, error) {
+	n, err := c.Count(ctx, s)
+	if err != nil {
+		return 0, err
+	}
+	return ramp(float64(n), float64(c.Low), float64(c.High)), nil
+}
+
+// ramp maps value onto 0..1: 0 at or below low, 1 at or above high, and linear in between.
+func ramp(value, low, high float64) float64 {
+	switch {
+	case value <= low:
+		return 0
+	case value >= high:
+		return 1
+	default:
+		return (value - low) / (high - low)
+	}
+}
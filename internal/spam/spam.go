@@ -0,0 +1,128 @@
+// Package spam scores new chirps and signups with a pipeline of pluggable signals, and decides what to do with them.
+package spam
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// An Action is what happens to a chirp or signup once it has been scored, from least to most severe.
+type Action string
+
+const (
+	ActionAllow  Action = "allow"  // published as normal
+	ActionQueue  Action = "queue"  // hidden from everyone but its author until a moderator approves it
+	ActionShadow Action = "shadow" // hidden from everyone but its author, without needing review
+	ActionReject Action = "reject" // refused outright
+)
+
+// A Subject is the chirp or signup being scored. Signals use whichever fields apply to them.
+type Subject struct {
+	UserID           uuid.UUID // uuid.Nil for signups
+	Email            string    // only set for signups
+	Body             string    // only set for chirps
+	AccountCreatedAt time.Time // only set for chirps
+}
+
+// A Signal scores one aspect of a subject between 0 (nothing suspicious) and 1 (certainly spam).
+type Signal interface {
+	Name() string
+	Score(ctx context.Context, s Subject) (float64, error)
+}
+
+// Thresholds are the total scores at which each action kicks in. A threshold of 0 or less disables its action.
+type Thresholds struct {
+	Queue  float64
+	Shadow float64
+	Reject float64
+}
+
+// DefaultThresholds are used for any threshold left unconfigured.
+var DefaultThresholds = Thresholds{Queue: 0.5, Shadow: 0.7, Reject: 0.9}
+
+// A SignalScore is the contribution of one signal to a verdict.
+type SignalScore struct {
+	Name   string
+	Score  float64 // the signal's own score, between 0 and 1
+	Weight float64
+}
+
+// A Verdict is the outcome of scoring a subject.
+type Verdict struct {
+	Action  Action
+	Score   float64       // the weighted sum of the signal scores
+	Signals []SignalScore // the signals that scored above 0, highest contribution first
+}
+
+// Reasons summarises the signals behind a verdict, e.g. "duplicate_body=1.00 link_density=0.50".
+func (v Verdict) Reasons() string {
+	reasons := make([]string, 0, len(v.Signals))
+	for _, s := range v.Signals {
+		reasons = append(reasons, fmt.Sprintf("%s=%.2f", s.Name, s.Score))
+	}
+	return strings.Join(reasons, " ")
+}
+
+type weightedSignal struct {
+	signal Signal
+	weight float64
+}
+
+// A Pipeline combines weighted signals into a verdict. Signals are added with Add before the pipeline is used;
+// after that it is safe for concurrent use.
+type Pipeline struct {
+	thresholds Thresholds
+	signals    []weightedSignal
+}
+
+// NewPipeline creates an empty pipeline that acts on the provided thresholds.
+func NewPipeline(t Thresholds) *Pipeline {
+	return &Pipeline{thresholds: t}
+}
+
+// Add adds a signal to the pipeline. Its score is multiplied by weight before being added to the total.
+func (p *Pipeline) Add(s Signal, weight float64) *Pipeline {
+	p.signals = append(p.signals, weightedSignal{signal: s, weight: weight})
+	return p
+}
+
+// Evaluate scores a subject with every signal and picks the most severe action whose threshold the total score reaches.
+// If a signal fails, the error is returned along with a verdict that allows the subject, so callers can choose to fail open.
+func (p *Pipeline) Evaluate(ctx context.Context, s Subject) (Verdict, error) {
+	verdict := Verdict{Action: ActionAllow}
+	for _, ws := range p.signals {
+		score, err := ws.signal.Score(ctx, s)
+		if err != nil {
+			return Verdict{Action: ActionAllow}, fmt.Errorf("spam signal %s: %w", ws.signal.Name(), err)
+		}
+		score = math.Max(0, math.Min(1, score))
+		if score == 0 {
+			continue
+		}
+		verdict.Score += score * ws.weight
+		verdict.Signals = append(verdict.Signals, SignalScore{Name: ws.signal.Name(), Score: score, Weight: ws.weight})
+	}
+	sort.SliceStable(verdict.Signals, func(i, j int) bool {
+		return verdict.Signals[i].Score*verdict.Signals[i].Weight > verdict.Signals[j].Score*verdict.Signals[j].Weight
+	})
+
+	switch {
+	case reaches(verdict.Score, p.thresholds.Reject):
+		verdict.Action = ActionReject
+	case reaches(verdict.Score, p.thresholds.Shadow):
+		verdict.Action = ActionShadow
+	case reaches(verdict.Score, p.thresholds.Queue):
+		verdict.Action = ActionQueue
+	}
+	return verdict, nil
+}
+
+func reaches(score, threshold float64) bool {
+	return threshold > 0 && score >= threshold
+}
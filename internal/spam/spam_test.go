@@ -0,0 +1,109 @@
+package spam
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// fixedSignal always returns the same score.
+type fixedSignal struct {
+	name  string
+	score float64
+	err   error
+}
+
+func (f fixedSignal) Name() string { return f.name }
+
+func (f fixedSignal) Score(context.Context, Subject) (float64, error) { return f.score, f.err }
+
+func TestPipelineActions(t *testing.T) {
+	cases := []struct {
+		score    float64
+		expected Action
+	}{
+		{score: 0, expected: ActionAllow},
+		{score: 0.49, expected: ActionAllow},
+		{score: 0.5, expected: ActionQueue},
+		{score: 0.75, expected: ActionShadow},
+		{score: 0.95, expected: ActionReject},
+	}
+
+	for _, c := range cases {
+		p := NewPipeline(DefaultThresholds).Add(fixedSignal{name: "fixed", score: c.score}, 1)
+		verdict, err := p.Evaluate(context.Background(), Subject{})
+		if err != nil {
+			t.Fatalf("Evaluate: %s", err)
+		}
+		if verdict.Action != c.expected {
+			t.Errorf("score %.2f: action = %s, expected %s", c.score, verdict.Action, c.expected)
+		}
+	}
+}
+
+func TestPipelineCombinesWeightedSignals(t *testing.T) {
+	p := NewPipeline(Thresholds{Reject: 0.9}).
+		Add(fixedSignal{name: "small", score: 0.5}, 0.2).
+		Add(fixedSignal{name: "quiet", score: 0}, 1).
+		Add(fixedSignal{name: "big", score: 2}, 0.8) // scores are clamped to 1
+
+	verdict, err := p.Evaluate(context.Background(), Subject{})
+	if err != nil {
+		t.Fatalf("Evaluate: %s", err)
+	}
+	if math.Abs(verdict.Score-0.9) > 1e-9 || verdict.Action != ActionReject {
+		t.Errorf("verdict = %+v, expected a rejection scoring 0.9", verdict)
+	}
+	if reasons := verdict.Reasons(); reasons != "big=1.00 small=0.50" {
+		t.Errorf("Reasons() = %q", reasons)
+	}
+}
+
+func TestPipelineFailsOpen(t *testing.T) {
+	p := NewPipeline(DefaultThresholds).Add(fixedSignal{name: "broken", score: 1, err: errors.New("db down")}, 1)
+	verdict, err := p.Evaluate(context.Background(), Subject{})
+	if err == nil || verdict.Action != ActionAllow {
+		t.Errorf("expected an error and an allow verdict, got %+v, %v", verdict, err)
+	}
+}
+
+func TestSignals(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	linkCases := []struct {
+		body string
+		min  float64
+		max  float64
+	}{
+		{body: "just words here", min: 0, max: 0},
+		{body: "https://spam.example", min: 1, max: 1},
+		{body: "a long chirp about many things, with one link at the end https://example.com", min: 0.2, max: 0.4},
+		{body: "https://a.example https://b.example https://c.example", min: 1, max: 10},
+	}
+	for _, c := range linkCases {
+		score, _ := LinkDensity{}.Score(ctx, Subject{Body: c.body})
+		if score < c.min || score > c.max {
+			t.Errorf("LinkDensity(%q) = %.2f, expected between %.2f and %.2f", c.body, score, c.min, c.max)
+		}
+	}
+
+	age := AccountAge{Min: time.Hour, Max: 25 * time.Hour, Now: func() time.Time { return now }}
+	ageCases := map[time.Duration]float64{30 * time.Minute: 1, 13 * time.Hour: 0.5, 48 * time.Hour: 0}
+	for accountAge, expected := range ageCases {
+		score, _ := age.Score(ctx, Subject{AccountCreatedAt: now.Add(-accountAge)})
+		if math.Abs(score-expected) > 1e-9 {
+			t.Errorf("AccountAge(%s) = %.2f, expected %.2f", accountAge, score, expected)
+		}
+	}
+
+	for count, expected := range map[int]float64{0: 0, 2: 0, 4: 0.5, 6: 1, 60: 1} {
+		signal := RecentCount{SignalName: "velocity", Low: 2, High: 6, Count: func(context.Context, Subject) (int, error) { return count, nil }}
+		score, _ := signal.Score(ctx, Subject{})
+		if score != expected {
+			t.Errorf("RecentCount(%d) = %.2f, expected %.2f", count, score, expected)
+		}
+	}
+}
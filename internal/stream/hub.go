@@ -0,0 +1,188 @@
+package stream
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Event types broadcast through the hub.
+const (
+	ChirpCreated  = "chirp.created"
+	ChirpDeleted  = "chirp.deleted"
+	ChirpRestored = "chirp.restored"
+	UserUpdated   = "user.updated"
+	UserUpgraded  = "user.upgraded"
+	// UserRelationshipsChanged is published for a user whose blocks, mutes or follows changed, including ones made by other users (e.g. being blocked).
+	UserRelationshipsChanged = "user.relationships_changed"
+)
+
+// IsChirpEvent reports whether an event is about a chirp (as opposed to a user), i.e. whether it belongs in a client's chirp stream.
+func IsChirpEvent(e Event) bool {
+	return e.Type == ChirpCreated || e.Type == ChirpDeleted || e.Type == ChirpRestored
+}
+
+// An Event is a change broadcast to stream subscribers.
+type Event struct {
+	// ID is assigned by the hub when the event is published, and increases monotonically.
+	ID       uint64
+	Type     string
+	AuthorID uuid.UUID
+	// AuthorPrivate is set when the author's account is private, so subscribers only deliver the event to the author and their followers.
+	AuthorPrivate bool
+	// Visibility is the chirp's audience ("public", "followers", "mentioned" or "unlisted"); empty for events that aren't about a chirp.
+	Visibility string
+	// MentionedUserIDs are the users mentioned by a created chirp, used to route notifications.
+	MentionedUserIDs []uuid.UUID
+	// Data is the JSON payload sent to clients.
+	Data json.RawMessage
+}
+
+// A Filter decides whether a subscriber receives an event. A nil Filter accepts every event.
+type Filter func(Event) bool
+
+// A Subscription receives the events published to a hub that match its filter.
+type Subscription struct {
+	hub    *Hub
+	filter Filter
+	events chan Event
+	closed bool // guarded by hub.mu
+}
+
+// Events returns the channel events are delivered on.
+// The channel is closed when the subscription is closed, either by the subscriber, by the hub shutting down, or by the hub dropping a subscriber that fell too far behind.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close unsubscribes from the hub. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// Hub fans out published events to subscribers.
+//
+// Publishing never blocks: each subscriber has a buffered channel, and a subscriber whose buffer is full is dropped rather than slowing down the publisher.
+// A dropped subscriber can reconnect and catch up from the hub's history of recent events.
+type Hub struct {
+	mu          sync.Mutex
+	lastID      uint64
+	history     []Event // most recent events, oldest first, capped at historySize
+	historySize int
+	bufferSize  int
+	subscribers map[*Subscription]struct{}
+	closed      bool
+	done        chan struct{}
+}
+
+// NewHub creates a hub that remembers the last historySize events for resuming subscribers, and buffers up to bufferSize undelivered events per subscriber.
+func NewHub(historySize, bufferSize int) *Hub {
+	return &Hub{
+		historySize: historySize,
+		bufferSize:  bufferSize,
+		subscribers: make(map[*Subscription]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Publish assigns the event an ID, records it in the hub's history and delivers it to every matching subscriber.
+// It returns the event with its ID set.
+func (h *Hub) Publish(e Event) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	e.ID = h.lastID
+
+	h.history = append(h.history, e)
+	if len(h.history) > h.historySize {
+		h.history = h.history[len(h.history)-h.historySize:]
+	}
+
+	for s := range h.subscribers {
+		if s.filter != nil && !s.filter(e) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			// slow consumer; drop it rather than block the publisher
+			h.remove(s)
+		}
+	}
+
+	return e
+}
+
+// Subscribe registers a new subscriber.
+//
+// If lastEventID is non-zero, matching events published after it that are still in the hub's history are queued on the subscription before any new events, so a reconnecting client misses nothing.
+// complete reports whether the history reached back far enough to replay every event since lastEventID.
+func (h *Hub) Subscribe(filter Filter, lastEventID uint64) (sub *Subscription, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var missed []Event
+	complete = true
+	if lastEventID != 0 {
+		if len(h.history) > 0 && h.history[0].ID > lastEventID+1 {
+			complete = false
+		}
+		for _, e := range h.history {
+			if e.ID > lastEventID && (filter == nil || filter(e)) {
+				missed = append(missed, e)
+			}
+		}
+	}
+
+	sub = &Subscription{
+		hub:    h,
+		filter: filter,
+		events: make(chan Event, h.bufferSize+len(missed)),
+	}
+	for _, e := range missed {
+		sub.events <- e
+	}
+
+	if h.closed {
+		sub.closed = true
+		close(sub.events)
+		return sub, complete
+	}
+	h.subscribers[sub] = struct{}{}
+	return sub, complete
+}
+
+// Close disconnects every subscriber and rejects new ones. Events published after Close are still recorded but not delivered.
+// It is safe to call more than once.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+	close(h.done)
+	for s := range h.subscribers {
+		h.remove(s)
+	}
+}
+
+// Done returns a channel that is closed when the hub is closed, letting subscribers tell a shutdown apart from being dropped.
+func (h *Hub) Done() <-chan struct{} {
+	return h.done
+}
+
+// remove unregisters a subscriber and closes its channel. h.mu must be held.
+func (h *Hub) remove(s *Subscription) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	delete(h.subscribers, s)
+	close(s.events)
+}
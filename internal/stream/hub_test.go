@@ -0,0 +1,82 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPublishDeliversToMatchingSubscribers(t *testing.T) {
+	hub := NewHub(10, 10)
+	author := uuid.New()
+
+	all, _ := hub.Subscribe(nil, 0)
+	byAuthor, _ := hub.Subscribe(func(e Event) bool { return e.AuthorID == author }, 0)
+
+	hub.Publish(Event{Type: ChirpCreated, AuthorID: uuid.New()})
+	hub.Publish(Event{Type: ChirpCreated, AuthorID: author})
+
+	if got := len(all.Events()); got != 2 {
+		t.Errorf("expected unfiltered subscriber to have 2 events, got %d", got)
+	}
+	if got := len(byAuthor.Events()); got != 1 {
+		t.Fatalf("expected filtered subscriber to have 1 event, got %d", got)
+	}
+	if e := <-byAuthor.Events(); e.AuthorID != author || e.ID != 2 {
+		t.Errorf("unexpected event delivered to filtered subscriber: %+v", e)
+	}
+}
+
+func TestSlowSubscriberIsDroppedWithoutBlocking(t *testing.T) {
+	hub := NewHub(10, 1)
+	slow, _ := hub.Subscribe(nil, 0)
+
+	// the second publish would block if the hub waited on the full buffer
+	hub.Publish(Event{Type: ChirpCreated})
+	hub.Publish(Event{Type: ChirpCreated})
+
+	<-slow.Events()
+	if _, open := <-slow.Events(); open {
+		t.Errorf("expected slow subscriber's channel to be closed after it was dropped")
+	}
+}
+
+func TestSubscribeResumesFromLastEventID(t *testing.T) {
+	hub := NewHub(3, 10)
+	for range 5 {
+		hub.Publish(Event{Type: ChirpCreated})
+	}
+
+	// events 3, 4 and 5 are still in history
+	sub, complete := hub.Subscribe(nil, 3)
+	if !complete {
+		t.Errorf("expected resume from event 3 to be complete")
+	}
+	if got := len(sub.Events()); got != 2 {
+		t.Errorf("expected 2 missed events to be replayed, got %d", got)
+	}
+
+	// event 2 has already fallen out of history
+	_, complete = hub.Subscribe(nil, 1)
+	if complete {
+		t.Errorf("expected resume from event 1 to be incomplete")
+	}
+}
+
+func TestCloseDisconnectsSubscribers(t *testing.T) {
+	hub := NewHub(10, 10)
+	sub, _ := hub.Subscribe(nil, 0)
+
+	hub.Close()
+	if _, open := <-sub.Events(); open {
+		t.Errorf("expected subscription to be closed when the hub closes")
+	}
+	select {
+	case <-hub.Done():
+	default:
+		t.Errorf("expected hub's Done channel to be closed")
+	}
+	// closing a subscription, or the hub, a second time must be safe
+	sub.Close()
+	hub.Close()
+}
@@ -0,0 +1,150 @@
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Channel is the Postgres NOTIFY channel chirpy instances broadcast events on.
+const Channel = "chirpy_events"
+
+// Postgres rejects NOTIFY payloads of 8000 bytes or more.
+const maxNotifyPayload = 7999
+
+// how long the listener waits between reconnection attempts, doubling up to the maximum
+const minReconnectInterval = time.Second
+const maxReconnectInterval = time.Minute
+
+// how often the listener checks its connection when no notifications are arriving
+const listenerPingInterval = 90 * time.Second
+
+// PGBridge relays events between chirpy instances using Postgres LISTEN/NOTIFY, so each instance's hub sees writes made on every instance.
+//
+// Events are published with NOTIFY rather than straight into the local hub; every instance (including the publisher) receives the notification and publishes it into its own hub.
+// Event IDs are therefore assigned by each instance's hub, and a client resuming with Last-Event-ID should reconnect to the same instance.
+type PGBridge struct {
+	db       *sql.DB
+	hub      *Hub
+	listener *pq.Listener
+}
+
+// NewPGBridge creates a bridge that publishes with db and listens on a dedicated connection opened from dsn, delivering received events into hub.
+// Call Run to start listening.
+func NewPGBridge(db *sql.DB, dsn string, hub *Hub) *PGBridge {
+	listener := pq.NewListener(dsn, minReconnectInterval, maxReconnectInterval, func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventDisconnected:
+			slog.Warn("event listener disconnected", "error", err)
+		case pq.ListenerEventConnectionAttemptFailed:
+			slog.Error("event listener failed to reconnect", "error", err)
+		case pq.ListenerEventReconnected:
+			slog.Warn("event listener reconnected; events published while disconnected were missed")
+		}
+	})
+	return &PGBridge{
+		db:       db,
+		hub:      hub,
+		listener: listener,
+	}
+}
+
+// Publish broadcasts an event to every instance (including this one) with NOTIFY.
+func (b *PGBridge) Publish(ctx context.Context, e Event) error {
+	payload, err := encodeEvent(e)
+	if err != nil {
+		return err
+	}
+	if _, err := b.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", Channel, payload); err != nil {
+		return fmt.Errorf("could not notify %s: %w", Channel, err)
+	}
+	return nil
+}
+
+// Run listens for events until ctx is cancelled, publishing each into the local hub.
+// The underlying connection is re-established automatically if it drops.
+// It is intended to be run in its own goroutine.
+func (b *PGBridge) Run(ctx context.Context) error {
+	defer b.listener.Close()
+
+	if err := b.listener.Listen(Channel); err != nil {
+		return fmt.Errorf("could not listen on %s: %w", Channel, err)
+	}
+
+	ping := time.NewTicker(listenerPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ping.C:
+			// a failed ping makes the listener notice a dead connection and reconnect
+			go b.listener.Ping()
+		case n := <-b.listener.Notify:
+			if n == nil {
+				// sent after a reconnect; nothing to deliver
+				continue
+			}
+			e, err := decodeEvent(n.Extra)
+			if err != nil {
+				slog.Error("could not decode event notification", "error", err)
+				continue
+			}
+			b.hub.Publish(e)
+		}
+	}
+}
+
+// wireEvent is the JSON form of an event sent as a NOTIFY payload.
+type wireEvent struct {
+	Type             string          `json:"type"`
+	AuthorID         uuid.UUID       `json:"author_id"`
+	AuthorPrivate    bool            `json:"author_private,omitempty"`
+	Visibility       string          `json:"visibility,omitempty"`
+	MentionedUserIDs []uuid.UUID     `json:"mentioned_user_ids,omitempty"`
+	Data             json.RawMessage `json:"data"`
+}
+
+func encodeEvent(e Event) (string, error) {
+	payload, err := json.Marshal(wireEvent{
+		Type:             e.Type,
+		AuthorID:         e.AuthorID,
+		AuthorPrivate:    e.AuthorPrivate,
+		Visibility:       e.Visibility,
+		MentionedUserIDs: e.MentionedUserIDs,
+		Data:             e.Data,
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not encode %s event: %w", e.Type, err)
+	}
+	if len(payload) > maxNotifyPayload {
+		return "", errors.New("event is too large to broadcast")
+	}
+	return string(payload), nil
+}
+
+func decodeEvent(payload string) (Event, error) {
+	var w wireEvent
+	if err := json.Unmarshal([]byte(payload), &w); err != nil {
+		return Event{}, err
+	}
+	if w.Type == "" {
+		return Event{}, errors.New("event has no type")
+	}
+	return Event{
+		Type:             w.Type,
+		AuthorID:         w.AuthorID,
+		AuthorPrivate:    w.AuthorPrivate,
+		Visibility:       w.Visibility,
+		MentionedUserIDs: w.MentionedUserIDs,
+		Data:             w.Data,
+	}, nil
+}
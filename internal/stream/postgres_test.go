@@ -0,0 +1,53 @@
+package stream
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestEncodeDecodeEvent(t *testing.T) {
+	original := Event{
+		ID:               42, // not sent; each instance's hub assigns its own
+		Type:             ChirpCreated,
+		AuthorID:         uuid.New(),
+		AuthorPrivate:    true,
+		Visibility:       "followers",
+		MentionedUserIDs: []uuid.UUID{uuid.New()},
+		Data:             []byte(`{"body":"hello"}`),
+	}
+
+	payload, err := encodeEvent(original)
+	if err != nil {
+		t.Fatalf("could not encode event: %v", err)
+	}
+	decoded, err := decodeEvent(payload)
+	if err != nil {
+		t.Fatalf("could not decode event: %v", err)
+	}
+
+	original.ID = 0
+	if !reflect.DeepEqual(decoded, original) {
+		t.Errorf("decoded event %+v does not match original %+v", decoded, original)
+	}
+}
+
+func TestEncodeEventRejectsOversizedPayloads(t *testing.T) {
+	_, err := encodeEvent(Event{
+		Type: ChirpCreated,
+		Data: []byte(`"` + strings.Repeat("a", maxNotifyPayload) + `"`),
+	})
+	if err == nil {
+		t.Errorf("expected an error encoding an event larger than the NOTIFY limit")
+	}
+}
+
+func TestDecodeEventRejectsInvalidPayloads(t *testing.T) {
+	for _, payload := range []string{"", "not json", "{}"} {
+		if _, err := decodeEvent(payload); err == nil {
+			t.Errorf("expected an error decoding %q", payload)
+		}
+	}
+}
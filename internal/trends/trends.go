@@ -0,0 +1,85 @@
+package trends
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// A Window is a sliding time window that trends are computed over.
+// Usage in the latest window is compared against usage in the window of the same length immediately before it.
+type Window struct {
+	Name   string
+	Length time.Duration
+}
+
+// Windows are the sliding windows trends are computed for, shortest first.
+var Windows = []Window{
+	{Name: "1h", Length: time.Hour},
+	{Name: "24h", Length: 24 * time.Hour},
+}
+
+// LookupWindow returns the window with the provided name, and whether it exists.
+func LookupWindow(name string) (Window, bool) {
+	for _, w := range Windows {
+		if w.Name == name {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
+
+// A tag must be used at least this many times in the current window to trend.
+const MinCurrentCount = 3
+
+// priorCount smooths the score of tags with little or no history, so that a brand new tag used a handful of times does not outrank an established tag that is genuinely surging.
+const priorCount = 2
+
+// Usage is how often a tag was used in the current and previous windows.
+type Usage struct {
+	Tag           string
+	CurrentCount  int64
+	PreviousCount int64
+}
+
+// A Trend is a tag with its velocity score.
+type Trend struct {
+	Usage
+	Score float64
+}
+
+// Score returns the velocity score for a tag: the growth from the previous window to the current one, scaled down by the square root of the previous window's usage.
+// Steady usage scores zero however large it is, while a sudden jump scores highly.
+func Score(current, previous int64) float64 {
+	return (float64(current) - float64(previous)) / math.Sqrt(float64(previous)+priorCount)
+}
+
+// Rank scores every tag, discards tags that are not trending (too few uses or no growth), and returns at most limit trends, highest score first.
+func Rank(usages []Usage, limit int) []Trend {
+	trends := []Trend{}
+	for _, u := range usages {
+		if u.CurrentCount < MinCurrentCount {
+			continue
+		}
+		score := Score(u.CurrentCount, u.PreviousCount)
+		if score <= 0 {
+			continue
+		}
+		trends = append(trends, Trend{Usage: u, Score: score})
+	}
+
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Score != trends[j].Score {
+			return trends[i].Score > trends[j].Score
+		}
+		if trends[i].CurrentCount != trends[j].CurrentCount {
+			return trends[i].CurrentCount > trends[j].CurrentCount
+		}
+		return trends[i].Tag < trends[j].Tag
+	})
+
+	if len(trends) > limit {
+		trends = trends[:limit]
+	}
+	return trends
+}
@@ -0,0 +1,48 @@
+package trends
+
+import (
+	"testing"
+)
+
+func TestScorePrefersVelocityOverRawCounts(t *testing.T) {
+	// a tag used constantly should not outrank a tag that is surging
+	steady := Score(500, 500)
+	surging := Score(40, 2)
+	if steady >= surging {
+		t.Errorf("expected surging tag score %v to beat steady tag score %v", surging, steady)
+	}
+	if steady != 0 {
+		t.Errorf("expected steady usage to score 0, got %v", steady)
+	}
+}
+
+func TestRank(t *testing.T) {
+	usages := []Usage{
+		{Tag: "steady", CurrentCount: 100, PreviousCount: 100},
+		{Tag: "rare", CurrentCount: 2, PreviousCount: 0},
+		{Tag: "dying", CurrentCount: 10, PreviousCount: 50},
+		{Tag: "rising", CurrentCount: 30, PreviousCount: 5},
+		{Tag: "new", CurrentCount: 10, PreviousCount: 0},
+	}
+
+	ranked := Rank(usages, 10)
+	if len(ranked) != 2 {
+		t.Fatalf("expected 2 trends, got %d: %+v", len(ranked), ranked)
+	}
+	if ranked[0].Tag != "rising" || ranked[1].Tag != "new" {
+		t.Errorf("unexpected trend order: %+v", ranked)
+	}
+
+	if limited := Rank(usages, 1); len(limited) != 1 {
+		t.Errorf("expected Rank to respect limit of 1, got %d trends", len(limited))
+	}
+}
+
+func TestLookupWindow(t *testing.T) {
+	if _, ok := LookupWindow("1h"); !ok {
+		t.Errorf("expected 1h window to exist")
+	}
+	if _, ok := LookupWindow("7d"); ok {
+		t.Errorf("did not expect 7d window to exist")
+	}
+}
@@ -1,15 +1,28 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
+	"net/netip"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	"github.com/rickNoise/chirpy/internal/config"
 	"github.com/rickNoise/chirpy/internal/database"
+	"github.com/rickNoise/chirpy/internal/linkpreview"
+	"github.com/rickNoise/chirpy/internal/logging"
+	"github.com/rickNoise/chirpy/internal/media"
+	"github.com/rickNoise/chirpy/internal/spam"
+	"github.com/rickNoise/chirpy/internal/stream"
 
 	_ "github.com/lib/pq"
 )
@@ -17,35 +30,130 @@ import (
 /* CONSTANTS */
 const port = "8080"
 const filepathRoot = "."
+const trendsRefreshInterval = time.Minute
+const userPurgeInterval = time.Hour
+const chirpPurgeInterval = time.Hour
+const chirpSchedulerInterval = 10 * time.Second
+const defaultMediaDir = "./media_uploads"
+
+// uploaded images are processed by a small pool of workers; pending media is swept into the queue periodically
+const mediaWorkers = 4
+const mediaQueueSize = 256
+const mediaSweepInterval = 30 * time.Second
+
+// link previews are fetched by their own pool of workers, so slow sites can't hold up media processing
+const linkPreviewWorkers = 4
+const linkPreviewQueueSize = 256
+const linkPreviewSweepInterval = 30 * time.Second
+
+// number of recent events kept for resuming streams, and buffered per subscriber
+const streamHistorySize = 1000
+const streamSubscriberBuffer = 64
+
+// how long in-flight requests get to finish when the server shuts down
+const shutdownTimeout = 10 * time.Second
 
 func main() {
+	// Cancelled on SIGINT/SIGTERM to stop background workers and the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Load environment variables
 	godotenv.Load()
 
+	// Log JSON lines at LOG_LEVEL (debug, info, warn or error; default info).
+	// The standard log package and slog's package-level functions write through the same logger.
+	logLevel, err := logging.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		fatal("invalid LOG_LEVEL", err)
+	}
+	logger := logging.New(os.Stdout, logLevel)
+	slog.SetDefault(logger)
+
 	// Create an instance of apiConfig
 	apiCfg := &config.ApiConfig{}
 
 	// Initialise platform
 	platform := os.Getenv("PLATFORM")
 	apiCfg.Platform = platform
-	fmt.Printf("initialised with platform: %s", platform)
+	slog.Info("initialised platform", "platform", platform)
 
 	// Load JWT_SECRET from .env & store in config
 	apiCfg.JWTSecret = os.Getenv("JWT_SECRET")
 	// Load our Polka API key from .env & store in config
 	apiCfg.PolkaKey = os.Getenv("POLKA_KEY")
+	// Load the key for the moderation endpoints; they are disabled when it isn't set
+	apiCfg.AdminKey = os.Getenv("ADMIN_KEY")
+	// Load the key for hashing email addresses in audit events; it is separate from JWT_SECRET so either can be rotated on its own
+	apiCfg.AuditHashKey = os.Getenv("AUDIT_HASH_KEY")
+	// Load the load balancers whose X-Forwarded-For header is believed
+	apiCfg.TrustedProxies = trustedProxiesFromEnv()
 
 	// Initialise database connection
 	dbURL := os.Getenv("DB_URL")
 	if dbURL == "" {
-		log.Fatal("DB_URL must be set in .env file")
+		fatal("DB_URL must be set in .env file", nil)
 	}
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
-		log.Fatalf("failed to connect to database: %s", err)
+		fatal("failed to connect to database", err)
 	}
 	apiCfg.DbQueries = database.New(db)
-	fmt.Println("successfully connected to db")
+	apiCfg.DB = db
+	slog.Info("successfully connected to db")
+
+	// Initialise the blob store for uploaded media
+	if os.Getenv("MEDIA_STORAGE") == "s3" {
+		apiCfg.Blobs = media.NewS3Store(
+			os.Getenv("S3_ENDPOINT"),
+			os.Getenv("S3_BUCKET"),
+			os.Getenv("S3_REGION"),
+			os.Getenv("S3_ACCESS_KEY_ID"),
+			os.Getenv("S3_SECRET_ACCESS_KEY"),
+		)
+	} else {
+		mediaDir := os.Getenv("MEDIA_DIR")
+		if mediaDir == "" {
+			mediaDir = defaultMediaDir
+		}
+		localStore, err := media.NewLocalStore(mediaDir)
+		if err != nil {
+			fatal("failed to initialise media storage", err)
+		}
+		apiCfg.Blobs = localStore
+	}
+	apiCfg.MediaQueue = make(chan uuid.UUID, mediaQueueSize)
+
+	// Link previews are fetched from arbitrary sites, so the fetcher refuses to connect to private addresses
+	apiCfg.LinkPreviews = linkpreview.NewFetcher(linkpreview.Options{})
+	apiCfg.LinkPreviewQueue = make(chan string, linkPreviewQueueSize)
+
+	// Score new chirps and signups for spam, acting on the thresholds set in the environment
+	spamThresholds := spamThresholdsFromEnv()
+	apiCfg.ChirpSpam = apiCfg.NewChirpSpamPipeline(spamThresholds)
+	apiCfg.SignupSpam = apiCfg.NewSignupSpamPipeline(spamThresholds)
+
+	// Create the hub that fans out real-time events
+	apiCfg.Stream = stream.NewHub(streamHistorySize, streamSubscriberBuffer)
+
+	// When running several instances, relay events between them through Postgres LISTEN/NOTIFY
+	if os.Getenv("EVENT_FANOUT") == "postgres" {
+		apiCfg.Broadcaster = stream.NewPGBridge(db, dbURL, apiCfg.Stream)
+		go func() {
+			if err := apiCfg.Broadcaster.Run(ctx); err != nil {
+				slog.Error("event fan-out stopped", "error", err)
+			}
+		}()
+		slog.Info("relaying events between instances via postgres")
+	}
+
+	/* BACKGROUND WORKERS */
+	go apiCfg.RunTrendsRefresher(ctx, trendsRefreshInterval)
+	go apiCfg.RunMediaProcessor(ctx, mediaWorkers, mediaSweepInterval)
+	go apiCfg.RunLinkPreviewFetcher(ctx, linkPreviewWorkers, linkPreviewSweepInterval)
+	go apiCfg.RunUserPurger(ctx, userPurgeInterval)
+	go apiCfg.RunChirpPurger(ctx, chirpPurgeInterval)
+	go apiCfg.RunChirpScheduler(ctx, chirpSchedulerInterval)
 
 	mux := http.NewServeMux()
 
@@ -63,24 +171,175 @@ func main() {
 	mux.HandleFunc("POST /api/login", apiCfg.HandleLogin)
 	mux.HandleFunc("POST /api/users", apiCfg.HandleCreateUser)
 	mux.HandleFunc("PUT /api/users", apiCfg.HandleUpdateUser)
+	mux.HandleFunc("PATCH /api/users/me", apiCfg.HandlePatchUser)
+	mux.HandleFunc("DELETE /api/users/me", apiCfg.HandleDeleteUser)
+	mux.HandleFunc("GET /api/users/me/export", apiCfg.HandleExportUser)
+	mux.HandleFunc("GET /api/users/{userID}", apiCfg.HandleGetUser)
+	mux.HandleFunc("POST /api/users/{userID}/block", apiCfg.HandleBlockUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/block", apiCfg.HandleUnblockUser)
+	mux.HandleFunc("GET /api/users/me/blocks", apiCfg.HandleGetBlocks)
+	mux.HandleFunc("POST /api/users/{userID}/mute", apiCfg.HandleMuteUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/mute", apiCfg.HandleUnmuteUser)
+	mux.HandleFunc("GET /api/users/me/mutes", apiCfg.HandleGetMutes)
+	mux.HandleFunc("POST /api/users/{userID}/follow", apiCfg.HandleFollowUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", apiCfg.HandleUnfollowUser)
+	mux.HandleFunc("GET /api/users/me/follow-requests", apiCfg.HandleGetFollowRequests)
+	mux.HandleFunc("POST /api/users/me/follow-requests/{userID}/approve", apiCfg.HandleApproveFollowRequest)
+	mux.HandleFunc("POST /api/users/me/follow-requests/{userID}/deny", apiCfg.HandleDenyFollowRequest)
 	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.HandleUpgradeUser)
 	mux.HandleFunc("POST /api/chirps", apiCfg.HandleCreateChirp)
+	mux.HandleFunc("POST /api/chirps/validate", apiCfg.HandleValidateChirp)
 	mux.HandleFunc("POST /api/refresh", apiCfg.HandleRefresh)
 	mux.HandleFunc("POST /api/revoke", apiCfg.HandleRevoke)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.HandleGetChirp)
 	mux.HandleFunc("GET /api/chirps", apiCfg.HandleGetAllChirps)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.HandleDeleteChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/restore", apiCfg.HandleRestoreChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/vote", apiCfg.HandleVoteInPoll)
+	// scheduled chirps live outside /api/chirps/, where /api/chirps/scheduled/{chirpID} would overlap with /api/chirps/{chirpID}/bookmark
+	mux.HandleFunc("GET /api/scheduled-chirps", apiCfg.HandleGetScheduledChirps)
+	mux.HandleFunc("PATCH /api/scheduled-chirps/{chirpID}", apiCfg.HandleUpdateScheduledChirp)
+	mux.HandleFunc("DELETE /api/scheduled-chirps/{chirpID}", apiCfg.HandleCancelScheduledChirp)
+	mux.HandleFunc("POST /api/drafts", apiCfg.HandleCreateDraft)
+	mux.HandleFunc("GET /api/drafts", apiCfg.HandleGetDrafts)
+	mux.HandleFunc("GET /api/drafts/{draftID}", apiCfg.HandleGetDraft)
+	mux.HandleFunc("PUT /api/drafts/{draftID}", apiCfg.HandleUpdateDraft)
+	mux.HandleFunc("DELETE /api/drafts/{draftID}", apiCfg.HandleDeleteDraft)
+	mux.HandleFunc("POST /api/drafts/{draftID}/publish", apiCfg.HandlePublishDraft)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", apiCfg.HandleBookmarkChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", apiCfg.HandleUnbookmarkChirp)
+	mux.HandleFunc("GET /api/bookmarks", apiCfg.HandleGetBookmarks)
+	mux.HandleFunc("POST /api/collections", apiCfg.HandleCreateCollection)
+	mux.HandleFunc("GET /api/collections", apiCfg.HandleGetCollections)
+	mux.HandleFunc("GET /api/collections/{collectionID}", apiCfg.HandleGetCollection)
+	mux.HandleFunc("PATCH /api/collections/{collectionID}", apiCfg.HandleUpdateCollection)
+	mux.HandleFunc("DELETE /api/collections/{collectionID}", apiCfg.HandleDeleteCollection)
+	mux.HandleFunc("GET /api/collections/{collectionID}/chirps", apiCfg.HandleGetCollectionChirps)
+	mux.HandleFunc("POST /api/collections/{collectionID}/chirps", apiCfg.HandleAddChirpToCollection)
+	mux.HandleFunc("PUT /api/collections/{collectionID}/chirps", apiCfg.HandleReorderCollection)
+	mux.HandleFunc("DELETE /api/collections/{collectionID}/chirps/{chirpID}", apiCfg.HandleRemoveChirpFromCollection)
+	mux.HandleFunc("POST /api/lists", apiCfg.HandleCreateUserList)
+	mux.HandleFunc("GET /api/lists", apiCfg.HandleGetUserLists)
+	mux.HandleFunc("GET /api/lists/subscriptions", apiCfg.HandleGetSubscribedUserLists)
+	mux.HandleFunc("GET /api/lists/{listID}", apiCfg.HandleGetUserList)
+	mux.HandleFunc("PATCH /api/lists/{listID}", apiCfg.HandleUpdateUserList)
+	mux.HandleFunc("DELETE /api/lists/{listID}", apiCfg.HandleDeleteUserList)
+	mux.HandleFunc("GET /api/lists/{listID}/members", apiCfg.HandleGetUserListMembers)
+	mux.HandleFunc("POST /api/lists/{listID}/members", apiCfg.HandleAddUserListMember)
+	mux.HandleFunc("DELETE /api/lists/{listID}/members/{userID}", apiCfg.HandleRemoveUserListMember)
+	mux.HandleFunc("POST /api/lists/{listID}/subscribe", apiCfg.HandleSubscribeToUserList)
+	mux.HandleFunc("DELETE /api/lists/{listID}/subscribe", apiCfg.HandleUnsubscribeFromUserList)
+	mux.HandleFunc("GET /api/lists/{listID}/chirps", apiCfg.HandleGetUserListChirps)
+	mux.HandleFunc("POST /api/conversations", apiCfg.HandleStartConversation)
+	mux.HandleFunc("GET /api/conversations", apiCfg.HandleGetConversations)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/messages", apiCfg.HandleSendDirectMessage)
+	mux.HandleFunc("GET /api/conversations/{conversationID}/messages", apiCfg.HandleGetDirectMessages)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/read", apiCfg.HandleMarkConversationRead)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", apiCfg.HandleGetChirpsByHashtag)
+	// GET /api/users/by-handle/{handle} and GET /api/users/{userID}/mentions both match "/api/users/by-handle/mentions",
+	// which ServeMux refuses to register, so both are routed through one pattern
+	mux.HandleFunc("GET /api/users/{userID}/{resource}", apiCfg.HandleGetUserResource)
+	mux.HandleFunc("GET /api/trends", apiCfg.HandleGetTrends)
+	mux.HandleFunc("POST /api/media", apiCfg.HandleUploadMedia)
+	mux.HandleFunc("GET /api/media/{mediaID}", apiCfg.HandleGetMedia)
+	mux.HandleFunc("GET /api/media/{mediaID}/content", apiCfg.HandleGetMediaContent)
+	mux.HandleFunc("GET /api/media/{mediaID}/thumbnails/{size}", apiCfg.HandleGetMediaThumbnail)
+	mux.HandleFunc("GET /api/stream", apiCfg.HandleStream)
+	mux.HandleFunc("GET /api/ws", apiCfg.HandleWebSocket)
 	mux.HandleFunc("GET /api/healthz", apiCfg.ReadinessHandler)
 
 	/* /ADMIN/ PATH PREFIX */
 	mux.HandleFunc("GET /admin/metrics", apiCfg.MetricsHandler)
 	mux.HandleFunc("POST /admin/reset", apiCfg.ResetHandler)
+	mux.HandleFunc("GET /admin/audit", apiCfg.HandleGetAuditEvents)
+	mux.HandleFunc("GET /admin/moderation/chirps", apiCfg.HandleGetModeratedChirps)
+	mux.HandleFunc("POST /admin/moderation/chirps/{chirpID}/approve", apiCfg.HandleApproveModeratedChirp)
+	mux.HandleFunc("POST /admin/moderation/chirps/{chirpID}/reject", apiCfg.HandleRejectModeratedChirp)
+	mux.HandleFunc("GET /admin/moderation/users", apiCfg.HandleGetModeratedUsers)
+	mux.HandleFunc("POST /admin/moderation/users/{userID}/approve", apiCfg.HandleApproveModeratedUser)
+	mux.HandleFunc("POST /admin/moderation/users/{userID}/reject", apiCfg.HandleRejectModeratedUser)
 
+	// Every request gets an X-Request-ID and a logger carrying it, and is logged once it completes
 	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: mux,
+		Addr:     ":" + port,
+		Handler:  logging.Middleware(logger, mux),
+		ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelError),
 	}
 
-	log.Printf("Serving files from %s on port: %s\n", filepathRoot, port)
-	log.Fatal(srv.ListenAndServe())
+	// Streaming connections never go idle, so Shutdown alone would wait on them until it times out.
+	// Closing the hub ends every SSE response and WebSocket connection cleanly.
+	srv.RegisterOnShutdown(apiCfg.Stream.Close)
+
+	// Shut down gracefully on SIGINT/SIGTERM
+	shutdownComplete := make(chan struct{})
+	go func() {
+		defer close(shutdownComplete)
+		<-ctx.Done()
+		slog.Info("shutting down server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			slog.Error("error during server shutdown", "error", err)
+		}
+	}()
+
+	slog.Info("serving", "root", filepathRoot, "port", port)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		fatal("server stopped", err)
+	}
+	<-shutdownComplete
+}
+
+// spamThresholdsFromEnv reads the spam score thresholds from SPAM_QUEUE_SCORE, SPAM_SHADOW_SCORE and SPAM_REJECT_SCORE,
+// falling back to the defaults for any that aren't set. Setting a threshold to 0 turns its action off.
+func spamThresholdsFromEnv() spam.Thresholds {
+	thresholds := spam.DefaultThresholds
+	for name, threshold := range map[string]*float64{
+		"SPAM_QUEUE_SCORE":  &thresholds.Queue,
+		"SPAM_SHADOW_SCORE": &thresholds.Shadow,
+		"SPAM_REJECT_SCORE": &thresholds.Reject,
+	} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			fatal(name+" must be a number", err)
+		}
+		*threshold = parsed
+	}
+	return thresholds
+}
+
+// trustedProxiesFromEnv reads TRUSTED_PROXIES, a comma-separated list of IP addresses and CIDR ranges (e.g. "10.0.0.0/8, 192.168.1.10").
+// It is empty when the variable isn't set, in which case X-Forwarded-For is ignored.
+func trustedProxiesFromEnv() []netip.Prefix {
+	var proxies []netip.Prefix
+	for _, value := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				fatal("TRUSTED_PROXIES must hold IP addresses or CIDR ranges", err)
+			}
+			proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			fatal("TRUSTED_PROXIES must hold IP addresses or CIDR ranges", err)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+	return proxies
+}
+
+// fatal logs an error that stops the server from starting, and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
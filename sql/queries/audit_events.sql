@@ -0,0 +1,55 @@
+-- name: CreateAuditEvent :exec
+-- appends an event to the audit log; actor_id and target_id may be NULL
+INSERT INTO
+    audit_events (
+        created_at,
+        event_type,
+        outcome,
+        actor_id,
+        target_id,
+        ip_address,
+        user_agent,
+        request_id,
+        details
+    )
+VALUES (
+        NOW(),
+        @event_type,
+        @outcome,
+        @actor_id,
+        @target_id,
+        @ip_address,
+        @user_agent,
+        @request_id,
+        @details
+    );
+
+-- name: GetAuditEventsPage :many
+-- Retrieves a page of audit events, newest first, starting after the provided (created_at, id) cursor.
+-- Filters left empty (an empty string, or the nil uuid for actor_id and target_id) match every event.
+SELECT *
+FROM audit_events
+WHERE (
+        @event_type::text = ''
+        OR event_type = @event_type::text
+    )
+    AND (
+        @outcome::text = ''
+        OR outcome = @outcome::text
+    )
+    AND (
+        @actor_id::uuid = '00000000-0000-0000-0000-000000000000'
+        OR actor_id = @actor_id::uuid
+    )
+    AND (
+        @target_id::uuid = '00000000-0000-0000-0000-000000000000'
+        OR target_id = @target_id::uuid
+    )
+    AND created_at >= @since::timestamp
+    AND created_at < @until::timestamp
+    AND (created_at, id) < (
+        @before_created_at::timestamp,
+        @before_id::uuid
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT @max_items;
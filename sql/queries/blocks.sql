@@ -0,0 +1,36 @@
+-- name: CreateBlock :exec
+-- records that a user has blocked another user; blocking someone twice is a no-op
+INSERT INTO
+    user_blocks (
+        blocker_id,
+        blocked_id,
+        created_at
+    )
+VALUES (@blocker_id, @blocked_id, NOW()) ON CONFLICT (blocker_id, blocked_id) DO NOTHING;
+
+-- name: DeleteBlock :execrows
+-- removes a block
+DELETE FROM user_blocks
+WHERE
+    blocker_id = @blocker_id
+    AND blocked_id = @blocked_id;
+
+-- name: GetBlocksByUser :many
+-- Retrieves the users blocked by the provided user, most recent first.
+SELECT *
+FROM user_blocks
+WHERE
+    blocker_id = @blocker_id
+ORDER BY created_at DESC;
+
+-- name: GetBlockRelatedUserIDs :many
+-- Retrieves the ids of users the provided user has blocked or been blocked by; chirps are hidden in both directions.
+SELECT blocked_id
+FROM user_blocks
+WHERE
+    blocker_id = @user_id
+UNION
+SELECT blocker_id
+FROM user_blocks
+WHERE
+    blocked_id = @user_id;
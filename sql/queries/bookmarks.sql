@@ -0,0 +1,30 @@
+-- name: CreateBookmark :exec
+-- bookmarks a chirp for a user; bookmarking it again is a no-op
+INSERT INTO
+    bookmarks (user_id, chirp_id, created_at)
+VALUES (@user_id, @chirp_id, NOW()) ON CONFLICT DO NOTHING;
+
+-- name: DeleteBookmark :execrows
+-- removes a bookmark
+DELETE FROM bookmarks WHERE user_id = @user_id AND chirp_id = @chirp_id;
+
+-- name: GetBookmarksPage :many
+-- Retrieves a page of the provided user's bookmarks, most recent first, starting after the provided (created_at, chirp_id) cursor.
+SELECT *
+FROM bookmarks
+WHERE
+    user_id = @user_id
+    AND (created_at, chirp_id) < (
+        @before_created_at::timestamp,
+        @before_chirp_id::uuid
+    )
+ORDER BY created_at DESC, chirp_id DESC
+LIMIT @max_bookmarks;
+
+-- name: GetBookmarksForUser :many
+-- Retrieves all of the provided user's bookmarks, most recent first, e.g. for a data export.
+SELECT *
+FROM bookmarks
+WHERE
+    user_id = @user_id
+ORDER BY created_at DESC, chirp_id DESC;
@@ -1,23 +1,45 @@
 -- name: CreateChirp :one
--- creates a new chirp in the db tied to the creating user
+-- creates a new chirp in the db tied to the creating user; a chirp with a publish_at is scheduled rather than published
 INSERT INTO
     chirps (
         created_at,
         updated_at,
         body,
-        user_id
+        user_id,
+        visibility,
+        publish_at
     )
-VALUES (NOW(), NOW(), @body, @user_id) RETURNING *;
+VALUES (
+        NOW(),
+        NOW(),
+        @body,
+        @user_id,
+        @visibility,
+        @publish_at
+    ) RETURNING *;
 
 -- name: GetAllChirps :many
 -- Retrieves all chirps in ascending order by created_at.
+-- Deleted chirps, and chirps by users whose accounts are pending deletion, are hidden from every read.
 SELECT
     id,
     created_at,
     updated_at,
     body,
-    user_id
+    user_id,
+    deleted_at,
+    visibility,
+    publish_at
 FROM chirps
+WHERE
+    deleted_at IS NULL
+    AND publish_at IS NULL
+    AND user_id IN (
+        SELECT id
+        FROM users
+        WHERE
+            deleted_at IS NULL
+    )
 ORDER BY created_at ASC;
 
 -- name: GetAllChirpsByAuthorUserId :many
@@ -27,10 +49,21 @@ SELECT
     created_at,
     updated_at,
     body,
-    user_id
+    user_id,
+    deleted_at,
+    visibility,
+    publish_at
 FROM chirps
 WHERE
     user_id = @user_id
+    AND deleted_at IS NULL
+    AND publish_at IS NULL
+    AND user_id IN (
+        SELECT id
+        FROM users
+        WHERE
+            deleted_at IS NULL
+    )
 ORDER BY created_at ASC;
 
 -- name: GetChirp :one
@@ -40,11 +73,143 @@ SELECT
     created_at,
     updated_at,
     body,
-    user_id
+    user_id,
+    deleted_at,
+    visibility,
+    publish_at
 FROM chirps
 WHERE
-    id = @chirpId;
+    id = @chirpId
+    AND deleted_at IS NULL
+    AND publish_at IS NULL
+    AND user_id IN (
+        SELECT id
+        FROM users
+        WHERE
+            deleted_at IS NULL
+    );
+
+-- name: GetChirpsByIDs :many
+-- Retrieves the chirps with the provided ids, in no particular order; ids of hidden or missing chirps are skipped.
+SELECT
+    id,
+    created_at,
+    updated_at,
+    body,
+    user_id,
+    deleted_at,
+    visibility,
+    publish_at
+FROM chirps
+WHERE
+    id = ANY (@chirp_ids::uuid[])
+    AND deleted_at IS NULL
+    AND publish_at IS NULL
+    AND user_id IN (
+        SELECT id
+        FROM users
+        WHERE
+            deleted_at IS NULL
+    );
 
 -- name: DeleteChirpById :one
--- Deletes the chirp with the provided chirp id (uuid)
-DELETE FROM chirps WHERE id = @chirpId RETURNING *;
\ No newline at end of file
+-- Soft-deletes the chirp with the provided chirp id (uuid); it can be restored until it is purged
+UPDATE chirps
+SET
+    updated_at = NOW(),
+    deleted_at = NOW()
+WHERE
+    id = @chirpId
+    AND deleted_at IS NULL RETURNING *;
+
+-- name: GetChirpIncludingDeleted :one
+-- Retrieves a single chirp based on provided chirp id, even if it has been deleted.
+SELECT * FROM chirps WHERE id = @chirpId;
+
+-- name: RestoreChirp :one
+-- Restores a deleted chirp
+UPDATE chirps
+SET
+    updated_at = NOW(),
+    deleted_at = NULL
+WHERE
+    id = @chirpId
+    AND deleted_at IS NOT NULL RETURNING *;
+
+-- name: GetDeletedChirpsForUser :many
+-- Retrieves the provided user's deleted chirps that haven't been purged yet; in ascending order by created_at.
+SELECT *
+FROM chirps
+WHERE
+    user_id = @user_id
+    AND deleted_at IS NOT NULL
+ORDER BY created_at ASC;
+
+-- name: GetChirpsDueForPurge :many
+-- Retrieves the ids of chirps deleted before the provided time, oldest first.
+SELECT id
+FROM chirps
+WHERE
+    deleted_at < @deleted_before
+ORDER BY deleted_at ASC
+LIMIT @max_chirps;
+
+-- name: PurgeChirps :exec
+-- Permanently deletes the provided chirps; their hashtags, mentions and media are removed by ON DELETE CASCADE
+DELETE FROM chirps WHERE id = ANY (@chirp_ids::uuid[]);
+
+-- name: GetScheduledChirpsForUser :many
+-- Retrieves the provided user's scheduled chirps, soonest first.
+SELECT *
+FROM chirps
+WHERE
+    user_id = @user_id
+    AND publish_at IS NOT NULL
+ORDER BY publish_at ASC;
+
+-- name: GetScheduledChirp :one
+-- Retrieves one of the provided user's scheduled chirps.
+SELECT *
+FROM chirps
+WHERE
+    id = @id
+    AND user_id = @user_id
+    AND publish_at IS NOT NULL;
+
+-- name: UpdateScheduledChirp :one
+-- Updates a scheduled chirp belonging to the provided user; returns no rows if it has already been published
+UPDATE chirps
+SET
+    updated_at = NOW(),
+    body = @body,
+    visibility = @visibility,
+    publish_at = @publish_at
+WHERE
+    id = @id
+    AND user_id = @user_id
+    AND publish_at IS NOT NULL RETURNING *;
+
+-- name: DeleteScheduledChirp :execrows
+-- Cancels (permanently deletes) a scheduled chirp belonging to the provided user, unless it has already been published
+DELETE FROM chirps
+WHERE
+    id = @id
+    AND user_id = @user_id
+    AND publish_at IS NOT NULL;
+
+-- name: PublishDueChirps :many
+-- Publishes scheduled chirps whose publish_at has passed, oldest first; created_at becomes the time they were published
+UPDATE chirps
+SET
+    created_at = NOW(),
+    updated_at = NOW(),
+    publish_at = NULL
+WHERE
+    id IN (
+        SELECT id
+        FROM chirps
+        WHERE
+            publish_at <= NOW()
+        ORDER BY publish_at ASC
+        LIMIT @max_chirps
+    ) RETURNING *;
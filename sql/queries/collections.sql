@@ -0,0 +1,96 @@
+-- name: CreateCollection :one
+-- creates a new, empty collection for the provided user
+INSERT INTO
+    collections (
+        created_at,
+        updated_at,
+        user_id,
+        name,
+        description,
+        is_public
+    )
+VALUES (
+        NOW(),
+        NOW(),
+        @user_id,
+        @name,
+        @description,
+        @is_public
+    ) RETURNING *;
+
+-- name: GetCollection :one
+-- Retrieves a collection, unless its owner's account is pending deletion.
+SELECT *
+FROM collections
+WHERE
+    id = @id
+    AND user_id IN (
+        SELECT id
+        FROM users
+        WHERE
+            deleted_at IS NULL
+    );
+
+-- name: GetCollectionsForUser :many
+-- Retrieves the provided user's collections, in alphabetical order.
+SELECT * FROM collections WHERE user_id = @user_id ORDER BY name ASC;
+
+-- name: UpdateCollection :one
+-- Updates a collection's name, description and visibility
+UPDATE collections
+SET
+    updated_at = NOW(),
+    name = @name,
+    description = @description,
+    is_public = @is_public
+WHERE
+    id = @id RETURNING *;
+
+-- name: DeleteCollection :exec
+-- Deletes a collection; its entries are removed by ON DELETE CASCADE, the chirps themselves are untouched
+DELETE FROM collections WHERE id = @id;
+
+-- name: AddChirpToCollection :execrows
+-- adds a chirp to the end of a collection; adding a chirp that is already in the collection is a no-op
+INSERT INTO
+    collection_chirps (
+        collection_id,
+        chirp_id,
+        position,
+        added_at
+    )
+VALUES (
+        @collection_id,
+        @chirp_id,
+        (
+            SELECT COALESCE(MAX(position) + 1, 0)
+            FROM collection_chirps
+            WHERE
+                collection_chirps.collection_id = @collection_id
+        ),
+        NOW()
+    ) ON CONFLICT DO NOTHING;
+
+-- name: RemoveChirpFromCollection :execrows
+-- removes a chirp from a collection
+DELETE FROM collection_chirps
+WHERE
+    collection_id = @collection_id
+    AND chirp_id = @chirp_id;
+
+-- name: SetCollectionChirpPosition :exec
+-- moves a chirp within a collection
+UPDATE collection_chirps
+SET
+    position = @position
+WHERE
+    collection_id = @collection_id
+    AND chirp_id = @chirp_id;
+
+-- name: GetCollectionChirpIDs :many
+-- Retrieves the ids of the chirps in a collection, in order.
+SELECT chirp_id
+FROM collection_chirps
+WHERE
+    collection_id = @collection_id
+ORDER BY position ASC, added_at ASC;
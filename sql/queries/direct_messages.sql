@@ -0,0 +1,116 @@
+-- name: CreateConversation :one
+-- starts a conversation between two users; user_a_id must sort before user_b_id
+INSERT INTO
+    conversations (
+        created_at,
+        updated_at,
+        user_a_id,
+        user_b_id
+    )
+VALUES (
+        NOW(),
+        NOW(),
+        @user_a_id,
+        @user_b_id
+    ) RETURNING *;
+
+-- name: GetConversation :one
+SELECT * FROM conversations WHERE id = @id;
+
+-- name: GetConversationBetween :one
+-- Retrieves the conversation between two users; user_a_id must sort before user_b_id.
+SELECT *
+FROM conversations
+WHERE
+    user_a_id = @user_a_id
+    AND user_b_id = @user_b_id;
+
+-- name: GetConversationsPage :many
+-- Retrieves a page of the provided user's conversations, most recently active first, starting after the provided (updated_at, id) cursor.
+SELECT *
+FROM conversations
+WHERE (
+        user_a_id = @user_id
+        OR user_b_id = @user_id
+    )
+    AND (updated_at, id) < (
+        @before_updated_at::timestamp,
+        @before_id::uuid
+    )
+ORDER BY updated_at DESC, id DESC
+LIMIT @max_conversations;
+
+-- name: GetConversationsForUser :many
+-- Retrieves all of the provided user's conversations, most recently active first, e.g. for a data export.
+SELECT *
+FROM conversations
+WHERE
+    user_a_id = @user_id
+    OR user_b_id = @user_id
+ORDER BY updated_at DESC, id DESC;
+
+-- name: GetUnreadMessageCounts :many
+-- Counts the messages the provided user hasn't read yet in each of the provided conversations; conversations without unread messages are left out.
+SELECT conversation_id, COUNT(*) AS unread_count
+FROM direct_messages
+WHERE
+    conversation_id = ANY (@conversation_ids::uuid[])
+    AND sender_id <> @user_id
+    AND read_at IS NULL
+GROUP BY
+    conversation_id;
+
+-- name: CreateDirectMessage :one
+-- sends a message, marking the conversation as active
+WITH
+    touched AS (
+        UPDATE conversations
+        SET
+            updated_at = NOW()
+        WHERE
+            id = @conversation_id
+    )
+INSERT INTO
+    direct_messages (
+        created_at,
+        conversation_id,
+        sender_id,
+        body
+    )
+VALUES (
+        NOW(),
+        @conversation_id,
+        @sender_id,
+        @body
+    ) RETURNING *;
+
+-- name: GetDirectMessagesPage :many
+-- Retrieves a page of a conversation's messages, newest first, starting after the provided (created_at, id) cursor.
+SELECT *
+FROM direct_messages
+WHERE
+    conversation_id = @conversation_id
+    AND (created_at, id) < (
+        @before_created_at::timestamp,
+        @before_id::uuid
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT @max_messages;
+
+-- name: GetDirectMessagesForConversation :many
+-- Retrieves all of a conversation's messages, oldest first, e.g. for a data export.
+SELECT *
+FROM direct_messages
+WHERE
+    conversation_id = @conversation_id
+ORDER BY created_at ASC, id ASC;
+
+-- name: MarkConversationRead :execrows
+-- marks the messages the provided user has received in a conversation as read
+UPDATE direct_messages
+SET
+    read_at = NOW()
+WHERE
+    conversation_id = @conversation_id
+    AND sender_id <> @user_id
+    AND read_at IS NULL;
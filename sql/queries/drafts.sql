@@ -0,0 +1,44 @@
+-- name: CreateDraft :one
+-- saves a new draft for the provided user
+INSERT INTO
+    drafts (
+        created_at,
+        updated_at,
+        user_id,
+        body,
+        visibility
+    )
+VALUES (
+        NOW(),
+        NOW(),
+        @user_id,
+        @body,
+        @visibility
+    ) RETURNING *;
+
+-- name: GetDraftsForUser :many
+-- Retrieves the provided user's drafts, most recently edited first.
+SELECT *
+FROM drafts
+WHERE
+    user_id = @user_id
+ORDER BY updated_at DESC;
+
+-- name: GetDraft :one
+-- Retrieves one of the provided user's drafts.
+SELECT * FROM drafts WHERE id = @id AND user_id = @user_id;
+
+-- name: UpdateDraft :one
+-- Replaces the contents of one of the provided user's drafts
+UPDATE drafts
+SET
+    updated_at = NOW(),
+    body = @body,
+    visibility = @visibility
+WHERE
+    id = @id
+    AND user_id = @user_id RETURNING *;
+
+-- name: DeleteDraft :one
+-- Deletes one of the provided user's drafts, returning it; used both to discard a draft and to take it for publishing
+DELETE FROM drafts WHERE id = @id AND user_id = @user_id RETURNING *;
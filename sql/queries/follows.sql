@@ -0,0 +1,94 @@
+-- name: CreateFollow :one
+-- follows a user, or requests to follow them if status is 'pending'; an existing follow or request is returned unchanged
+INSERT INTO
+    follows (
+        follower_id,
+        followee_id,
+        status,
+        created_at,
+        updated_at
+    )
+VALUES (
+        @follower_id,
+        @followee_id,
+        @status,
+        NOW(),
+        NOW()
+    ) ON CONFLICT (follower_id, followee_id) DO
+UPDATE
+SET
+    updated_at = follows.updated_at RETURNING *;
+
+-- name: DeleteFollow :execrows
+-- unfollows a user, or cancels a pending follow request
+DELETE FROM follows
+WHERE
+    follower_id = @follower_id
+    AND followee_id = @followee_id;
+
+-- name: DeleteFollowsBetween :exec
+-- removes any follows or follow requests between two users, in both directions
+DELETE FROM follows
+WHERE (
+        follower_id = @user_id
+        AND followee_id = @other_user_id
+    )
+    OR (
+        follower_id = @other_user_id
+        AND followee_id = @user_id
+    );
+
+-- name: AcceptFollowRequest :execrows
+-- approves a pending follow request
+UPDATE follows
+SET
+    updated_at = NOW(),
+    status = 'accepted'
+WHERE
+    follower_id = @follower_id
+    AND followee_id = @followee_id
+    AND status = 'pending';
+
+-- name: DenyFollowRequest :execrows
+-- denies (deletes) a pending follow request
+DELETE FROM follows
+WHERE
+    follower_id = @follower_id
+    AND followee_id = @followee_id
+    AND status = 'pending';
+
+-- name: AcceptAllFollowRequests :many
+-- approves every pending follow request to a user, e.g. when their account becomes public, returning the ids of the approved followers
+UPDATE follows
+SET
+    updated_at = NOW(),
+    status = 'accepted'
+WHERE
+    followee_id = @followee_id
+    AND status = 'pending' RETURNING follower_id;
+
+-- name: GetFollowRequests :many
+-- Retrieves the pending follow requests to the provided user, most recent first.
+SELECT *
+FROM follows
+WHERE
+    followee_id = @followee_id
+    AND status = 'pending'
+ORDER BY created_at DESC;
+
+-- name: GetFolloweeIDs :many
+-- Retrieves the ids of the users the provided user follows (accepted follows only).
+SELECT followee_id
+FROM follows
+WHERE
+    follower_id = @follower_id
+    AND status = 'accepted';
+
+-- name: GetFollowsForUser :many
+-- Retrieves every follow and follow request from or to the provided user, most recent first.
+SELECT *
+FROM follows
+WHERE
+    follower_id = @user_id
+    OR followee_id = @user_id
+ORDER BY created_at DESC;
@@ -0,0 +1,60 @@
+-- name: CreateChirpHashtag :exec
+-- records a hashtag parsed from a chirp body, along with its rune offsets in the body
+INSERT INTO
+    chirp_hashtags (
+        chirp_id,
+        tag,
+        start_offset,
+        end_offset
+    )
+VALUES (
+        @chirp_id,
+        @tag,
+        @start_offset,
+        @end_offset
+    );
+
+-- name: DeleteHashtagsForChirp :exec
+-- removes a chirp's hashtags, e.g. before re-parsing an edited body
+DELETE FROM chirp_hashtags WHERE chirp_id = @chirp_id;
+
+-- name: GetHashtagsForChirps :many
+-- Retrieves the hashtag entities for all of the provided chirp ids, ordered by their position in each chirp.
+SELECT
+    chirp_id,
+    tag,
+    start_offset,
+    end_offset
+FROM chirp_hashtags
+WHERE
+    chirp_id = ANY (@chirp_ids::uuid[])
+ORDER BY chirp_id, start_offset ASC;
+
+-- name: GetChirpsByHashtag :many
+-- Retrieves all chirps tagged with the provided (lowercased) hashtag; in ascending order by created_at.
+SELECT
+    id,
+    created_at,
+    updated_at,
+    body,
+    user_id,
+    deleted_at,
+    visibility,
+    publish_at
+FROM chirps
+WHERE
+    deleted_at IS NULL
+    AND publish_at IS NULL
+    AND id IN (
+        SELECT chirp_id
+        FROM chirp_hashtags
+        WHERE
+            tag = @tag
+    )
+    AND user_id IN (
+        SELECT id
+        FROM users
+        WHERE
+            deleted_at IS NULL
+    )
+ORDER BY created_at ASC;
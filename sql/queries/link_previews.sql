@@ -0,0 +1,87 @@
+-- name: RequestLinkPreview :exec
+-- queues a url for fetching, unless its preview is already cached and was fetched after stale_before
+INSERT INTO
+    link_previews (url, created_at, updated_at)
+VALUES (@url, NOW(), NOW()) ON CONFLICT (url) DO
+UPDATE
+SET
+    updated_at = NOW(),
+    fetch_status = 'pending'
+WHERE
+    link_previews.fetch_status IN ('ready', 'failed')
+    AND link_previews.fetched_at < @stale_before::timestamp;
+
+-- name: CreateChirpLinkPreview :exec
+INSERT INTO chirp_link_previews (chirp_id, url) VALUES (@chirp_id, @url);
+
+-- name: DeleteChirpLinkPreview :exec
+DELETE FROM chirp_link_previews WHERE chirp_id = @chirp_id;
+
+-- name: GetChirpLinkPreviews :many
+-- Retrieves the links shown as preview cards by any of the provided chirps.
+SELECT *
+FROM chirp_link_previews
+WHERE
+    chirp_id = ANY (@chirp_ids::uuid[]);
+
+-- name: GetReadyLinkPreviews :many
+-- Retrieves the cached previews of any of the provided urls that have been fetched successfully.
+SELECT *
+FROM link_previews
+WHERE
+    url = ANY (@urls::text[])
+    AND fetch_status = 'ready';
+
+-- name: GetPendingLinkPreviewURLs :many
+-- Retrieves the urls waiting to be fetched, oldest first.
+SELECT url
+FROM link_previews
+WHERE
+    fetch_status = 'pending'
+ORDER BY created_at ASC
+LIMIT @max_urls;
+
+-- name: ClaimLinkPreviewForFetching :one
+-- marks a pending url as being fetched; returns no rows if another worker already claimed it
+UPDATE link_previews
+SET
+    updated_at = NOW(),
+    fetch_status = 'fetching'
+WHERE
+    url = @url
+    AND fetch_status = 'pending' RETURNING *;
+
+-- name: CompleteLinkPreview :exec
+-- stores the fetched preview of a url
+UPDATE link_previews
+SET
+    updated_at = NOW(),
+    fetched_at = NOW(),
+    fetch_status = 'ready',
+    canonical_url = @canonical_url,
+    title = @title,
+    description = @description,
+    image_url = @image_url,
+    site_name = @site_name
+WHERE
+    url = @url;
+
+-- name: FailLinkPreview :exec
+-- marks a url as having no preview, which is cached like a successful fetch
+UPDATE link_previews
+SET
+    updated_at = NOW(),
+    fetched_at = NOW(),
+    fetch_status = 'failed'
+WHERE
+    url = @url;
+
+-- name: ResetStaleLinkPreviewFetches :execrows
+-- returns urls stuck being fetched (e.g. because an instance crashed mid-way) to the pending queue
+UPDATE link_previews
+SET
+    updated_at = NOW(),
+    fetch_status = 'pending'
+WHERE
+    fetch_status = 'fetching'
+    AND updated_at < @stale_before;
@@ -0,0 +1,3 @@
+-- name: TryAdvisoryXactLock :one
+-- tries to take a transaction-scoped advisory lock, returning false if another session holds it; the lock is released when the transaction ends
+SELECT pg_try_advisory_xact_lock(@lock_id::bigint) AS locked;
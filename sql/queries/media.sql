@@ -0,0 +1,151 @@
+-- name: CreateMedia :one
+-- records an uploaded media item, not yet attached to any chirp
+INSERT INTO
+    media (
+        id,
+        created_at,
+        updated_at,
+        user_id,
+        storage_key,
+        content_type,
+        size_bytes,
+        width,
+        height
+    )
+VALUES (
+        @id,
+        NOW(),
+        NOW(),
+        @user_id,
+        @storage_key,
+        @content_type,
+        @size_bytes,
+        @width,
+        @height
+    ) RETURNING *;
+
+-- name: GetMedia :one
+-- Retrieves a single media item based on provided media id.
+SELECT * FROM media WHERE id = @mediaId;
+
+-- name: AttachMediaToChirp :execrows
+-- attaches a media item to a chirp, but only if it belongs to the provided user and is not already attached
+UPDATE media
+SET
+    updated_at = NOW(),
+    chirp_id = @chirp_id,
+    position = @position
+WHERE
+    id = @id
+    AND user_id = @user_id
+    AND chirp_id IS NULL;
+
+-- name: GetMediaForChirps :many
+-- Retrieves the media attached to all of the provided chirp ids, in their order within each chirp.
+SELECT *
+FROM media
+WHERE
+    chirp_id = ANY (@chirp_ids::uuid[])
+ORDER BY chirp_id, position ASC;
+
+-- name: GetPendingMediaIDs :many
+-- Retrieves the ids of media waiting to be processed, oldest first.
+SELECT id
+FROM media
+WHERE
+    processing_status = 'pending'
+ORDER BY created_at ASC
+LIMIT @max_media;
+
+-- name: ClaimMediaForProcessing :one
+-- marks a pending media item as being processed; returns no rows if another worker already claimed it
+UPDATE media
+SET
+    updated_at = NOW(),
+    processing_status = 'processing'
+WHERE
+    id = @id
+    AND processing_status = 'pending' RETURNING *;
+
+-- name: CompleteMediaProcessing :one
+-- replaces a media item's blob with its processed (metadata-stripped) version and marks it ready
+UPDATE media
+SET
+    updated_at = NOW(),
+    processed_at = NOW(),
+    processing_status = 'ready',
+    storage_key = @storage_key,
+    content_type = @content_type,
+    size_bytes = @size_bytes,
+    width = @width,
+    height = @height,
+    blurhash = @blurhash
+WHERE
+    id = @id RETURNING *;
+
+-- name: FailMediaProcessing :exec
+-- marks a media item as having failed processing
+UPDATE media
+SET
+    updated_at = NOW(),
+    processed_at = NOW(),
+    processing_status = 'failed'
+WHERE
+    id = @id;
+
+-- name: ResetStaleMediaProcessing :execrows
+-- returns media stuck in processing (e.g. because an instance crashed mid-way) to the pending queue
+UPDATE media
+SET
+    updated_at = NOW(),
+    processing_status = 'pending'
+WHERE
+    processing_status = 'processing'
+    AND updated_at < @stale_before;
+
+-- name: CreateMediaThumbnail :exec
+-- records a thumbnail generated for a media item, replacing any previous thumbnail of the same size
+INSERT INTO
+    media_thumbnails (
+        media_id,
+        size_name,
+        storage_key,
+        content_type,
+        width,
+        height
+    )
+VALUES (
+        @media_id,
+        @size_name,
+        @storage_key,
+        @content_type,
+        @width,
+        @height
+    ) ON CONFLICT (media_id, size_name) DO
+UPDATE
+SET
+    storage_key = EXCLUDED.storage_key,
+    content_type = EXCLUDED.content_type,
+    width = EXCLUDED.width,
+    height = EXCLUDED.height;
+
+-- name: GetThumbnailsForMedia :many
+-- Retrieves the thumbnails for all of the provided media ids.
+SELECT *
+FROM media_thumbnails
+WHERE
+    media_id = ANY (@media_ids::uuid[])
+ORDER BY media_id, width ASC;
+
+-- name: GetMediaThumbnail :one
+-- Retrieves a single thumbnail of a media item by size name.
+SELECT *
+FROM media_thumbnails
+WHERE
+    media_id = @media_id
+    AND size_name = @size_name;
+
+
+-- name: GetMediaForUser :many
+-- Retrieves every media item uploaded by the provided user, oldest first.
+SELECT * FROM media WHERE user_id = @user_id ORDER BY created_at ASC;
\ No newline at end of file
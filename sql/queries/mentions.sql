@@ -0,0 +1,63 @@
+-- name: CreateChirpMention :exec
+-- records a mention of a user parsed from a chirp body, along with its rune offsets in the body
+INSERT INTO
+    chirp_mentions (
+        chirp_id,
+        user_id,
+        handle,
+        start_offset,
+        end_offset
+    )
+VALUES (
+        @chirp_id,
+        @user_id,
+        @handle,
+        @start_offset,
+        @end_offset
+    );
+
+-- name: DeleteMentionsForChirp :exec
+-- removes a chirp's mentions, e.g. before re-parsing an edited body
+DELETE FROM chirp_mentions WHERE chirp_id = @chirp_id;
+
+-- name: GetMentionsForChirps :many
+-- Retrieves the mention entities for all of the provided chirp ids, ordered by their position in each chirp.
+SELECT
+    chirp_id,
+    user_id,
+    handle,
+    start_offset,
+    end_offset
+FROM chirp_mentions
+WHERE
+    chirp_id = ANY (@chirp_ids::uuid[])
+ORDER BY chirp_id, start_offset ASC;
+
+-- name: GetChirpsMentioningUser :many
+-- Retrieves all chirps that mention the provided user id; in ascending order by created_at.
+SELECT
+    id,
+    created_at,
+    updated_at,
+    body,
+    user_id,
+    deleted_at,
+    visibility,
+    publish_at
+FROM chirps
+WHERE
+    deleted_at IS NULL
+    AND publish_at IS NULL
+    AND id IN (
+        SELECT chirp_id
+        FROM chirp_mentions
+        WHERE
+            chirp_mentions.user_id = @user_id
+    )
+    AND user_id IN (
+        SELECT id
+        FROM users
+        WHERE
+            deleted_at IS NULL
+    )
+ORDER BY created_at ASC;
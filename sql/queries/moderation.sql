@@ -0,0 +1,127 @@
+-- name: CountRecentChirpsWithBody :one
+-- counts the chirps created since the provided time with exactly the provided body, by any user
+SELECT COUNT(*) AS chirp_count
+FROM chirps
+WHERE
+    md5(body) = md5(@body::text)
+    AND body = @body::text
+    AND created_at >= @since::timestamp
+    AND deleted_at IS NULL;
+
+-- name: CountRecentChirpsByUser :one
+-- counts the chirps the provided user has created since the provided time
+SELECT COUNT(*) AS chirp_count
+FROM chirps
+WHERE
+    user_id = @user_id
+    AND created_at >= @since::timestamp;
+
+-- name: CountRecentSignupsForEmailDomain :one
+-- counts the accounts created since the provided time with an email address at the provided domain (compared case-insensitively)
+SELECT COUNT(*) AS signup_count
+FROM users
+WHERE
+    LOWER(SPLIT_PART(email, '@', 2)) = LOWER(@domain::text)
+    AND created_at >= @since::timestamp;
+
+-- name: CreateChirpModeration :exec
+-- flags a chirp for moderation; status is 'queued' or 'hidden'
+-- a chirp that is already flagged (e.g. a scheduled chirp that was edited) keeps its existing flag
+INSERT INTO
+    chirp_moderation (
+        chirp_id,
+        created_at,
+        status,
+        score,
+        reasons
+    )
+VALUES (
+        @chirp_id,
+        NOW(),
+        @status,
+        @score,
+        @reasons
+    ) ON CONFLICT (chirp_id) DO NOTHING;
+
+-- name: CreateUserModeration :exec
+-- flags a new account for moderation; status is 'queued' or 'hidden'
+INSERT INTO
+    user_moderation (
+        user_id,
+        created_at,
+        status,
+        score,
+        reasons
+    )
+VALUES (
+        @user_id,
+        NOW(),
+        @status,
+        @score,
+        @reasons
+    );
+
+-- name: GetModeratedChirpIDs :many
+-- Retrieves which of the provided chirp ids are hidden by moderation, either flagged themselves or written by a flagged account.
+SELECT id
+FROM chirps
+WHERE
+    id = ANY (@chirp_ids::uuid[])
+    AND (
+        id IN (
+            SELECT chirp_id
+            FROM chirp_moderation
+        )
+        OR user_id IN (
+            SELECT user_id
+            FROM user_moderation
+        )
+    );
+
+-- name: GetChirpModerationQueue :many
+-- Retrieves the flagged chirps with the provided status that haven't been deleted, oldest first.
+SELECT
+    chirp_moderation.chirp_id,
+    chirp_moderation.created_at,
+    chirp_moderation.status,
+    chirp_moderation.score,
+    chirp_moderation.reasons
+FROM chirp_moderation
+    JOIN chirps ON chirps.id = chirp_moderation.chirp_id
+WHERE
+    chirp_moderation.status = @status
+    AND chirps.deleted_at IS NULL
+ORDER BY chirp_moderation.created_at ASC, chirp_moderation.chirp_id ASC
+LIMIT @max_items;
+
+-- name: GetUserModerationQueue :many
+-- Retrieves the flagged accounts with the provided status that haven't been deleted, oldest first.
+SELECT
+    user_moderation.user_id,
+    user_moderation.created_at,
+    user_moderation.status,
+    user_moderation.score,
+    user_moderation.reasons,
+    users.email,
+    users.handle
+FROM user_moderation
+    JOIN users ON users.id = user_moderation.user_id
+WHERE
+    user_moderation.status = @status
+    AND users.deleted_at IS NULL
+ORDER BY user_moderation.created_at ASC, user_moderation.user_id ASC
+LIMIT @max_items;
+
+-- name: GetChirpModeration :one
+SELECT * FROM chirp_moderation WHERE chirp_id = @chirp_id;
+
+-- name: GetUserModeration :one
+SELECT * FROM user_moderation WHERE user_id = @user_id;
+
+-- name: DeleteChirpModeration :execrows
+-- approves a flagged chirp, making it visible to its audience
+DELETE FROM chirp_moderation WHERE chirp_id = @chirp_id;
+
+-- name: DeleteUserModeration :execrows
+-- approves a flagged account, making its chirps visible to their audiences
+DELETE FROM user_moderation WHERE user_id = @user_id;
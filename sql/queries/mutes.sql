@@ -0,0 +1,24 @@
+-- name: CreateMute :exec
+-- records that a user has muted another user; muting someone twice is a no-op
+INSERT INTO
+    user_mutes (muter_id, muted_id, created_at)
+VALUES (@muter_id, @muted_id, NOW()) ON CONFLICT (muter_id, muted_id) DO NOTHING;
+
+-- name: DeleteMute :execrows
+-- removes a mute
+DELETE FROM user_mutes
+WHERE
+    muter_id = @muter_id
+    AND muted_id = @muted_id;
+
+-- name: GetMutesByUser :many
+-- Retrieves the users muted by the provided user, most recent first.
+SELECT *
+FROM user_mutes
+WHERE
+    muter_id = @muter_id
+ORDER BY created_at DESC;
+
+-- name: GetMutedUserIDs :many
+-- Retrieves the ids of users muted by the provided user.
+SELECT muted_id FROM user_mutes WHERE muter_id = @muter_id;
@@ -0,0 +1,62 @@
+-- name: CreatePoll :exec
+-- attaches a poll to a chirp; its options are added with CreatePollOption
+INSERT INTO
+    polls (chirp_id, duration_minutes)
+VALUES (@chirp_id, @duration_minutes);
+
+-- name: CreatePollOption :exec
+INSERT INTO
+    poll_options (chirp_id, position, text)
+VALUES (@chirp_id, @position, @text);
+
+-- name: GetPollsForChirps :many
+-- Retrieves the polls attached to any of the provided chirps.
+SELECT * FROM polls WHERE chirp_id = ANY (@chirp_ids::uuid[]);
+
+-- name: GetPollOptionsForChirps :many
+-- Retrieves the options of the polls attached to any of the provided chirps, in order, with their vote counts.
+SELECT
+    chirp_id,
+    position,
+    text,
+    (
+        SELECT COUNT(*)
+        FROM poll_votes
+        WHERE
+            poll_votes.chirp_id = poll_options.chirp_id
+            AND poll_votes.position = poll_options.position
+    ) AS votes
+FROM poll_options
+WHERE
+    chirp_id = ANY (@chirp_ids::uuid[])
+ORDER BY chirp_id, position ASC;
+
+-- name: GetPollVotesByUser :many
+-- Retrieves the provided user's votes in the polls attached to any of the provided chirps.
+SELECT *
+FROM poll_votes
+WHERE
+    user_id = @user_id
+    AND chirp_id = ANY (@chirp_ids::uuid[]);
+
+-- name: GetPollVotesForUser :many
+-- Retrieves every vote the provided user has cast, oldest first.
+SELECT * FROM poll_votes WHERE user_id = @user_id ORDER BY created_at ASC;
+
+-- name: CreatePollVote :execrows
+-- records a user's vote while the poll is still open (counted from the chirp's created_at, like pollClosesAt);
+-- nothing is recorded if the poll has closed or the user has already voted in it
+INSERT INTO
+    poll_votes (
+        chirp_id,
+        user_id,
+        position,
+        created_at
+    )
+SELECT polls.chirp_id, @user_id::uuid, @position::integer, NOW()
+FROM polls
+    JOIN chirps ON chirps.id = polls.chirp_id
+WHERE
+    polls.chirp_id = @chirp_id
+    AND chirps.created_at + polls.duration_minutes * INTERVAL '1 minute' > NOW()
+ON CONFLICT DO NOTHING;
@@ -39,4 +39,18 @@ SET
     updated_at = NOW(),
     revoked_at = NOW()
 WHERE
-    token = @token RETURNING *;
\ No newline at end of file
+    token = @token RETURNING *;
+
+-- name: RevokeAllRefreshTokensForUser :exec
+-- revokes every refresh token belonging to a user that has not already been revoked
+UPDATE refresh_tokens
+SET
+    updated_at = NOW(),
+    revoked_at = NOW()
+WHERE
+    user_id = @user_id
+    AND revoked_at IS NULL;
+
+-- name: GetRefreshTokensForUser :many
+-- Retrieves every refresh token issued to the provided user, newest first.
+SELECT * FROM refresh_tokens WHERE user_id = @user_id ORDER BY created_at DESC;
\ No newline at end of file
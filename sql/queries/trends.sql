@@ -0,0 +1,71 @@
+-- name: CountHashtagUsageForWindow :many
+-- counts how often each hashtag was used in the current window (on or after window_start)
+-- and in the previous window (between previous_window_start and window_start)
+-- only public chirps by public accounts count, since trends are shown to everyone; chirps hidden by moderation don't count either
+SELECT
+    chirp_hashtags.tag,
+    COUNT(*) FILTER (
+        WHERE
+            chirps.created_at >= @window_start::timestamp
+    ) AS current_count,
+    COUNT(*) FILTER (
+        WHERE
+            chirps.created_at < @window_start::timestamp
+    ) AS previous_count
+FROM chirp_hashtags
+    JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE
+    chirps.created_at >= @previous_window_start::timestamp
+    AND chirps.deleted_at IS NULL
+    AND chirps.publish_at IS NULL
+    AND chirps.visibility = 'public'
+    AND chirps.user_id IN (
+        SELECT id
+        FROM users
+        WHERE
+            deleted_at IS NULL
+            AND is_private = FALSE
+    )
+    AND chirps.id NOT IN (
+        SELECT chirp_id
+        FROM chirp_moderation
+    )
+    AND chirps.user_id NOT IN (
+        SELECT user_id
+        FROM user_moderation
+    )
+GROUP BY
+    chirp_hashtags.tag;
+
+-- name: DeleteTrendsForWindow :exec
+-- clears the materialized trends for a window before they are rebuilt
+DELETE FROM hashtag_trends WHERE window_name = @window_name;
+
+-- name: CreateTrend :exec
+-- stores a single materialized trend for a window
+INSERT INTO
+    hashtag_trends (
+        window_name,
+        tag,
+        score,
+        current_count,
+        previous_count,
+        computed_at
+    )
+VALUES (
+        @window_name,
+        @tag,
+        @score,
+        @current_count,
+        @previous_count,
+        @computed_at
+    );
+
+-- name: GetTrendsForWindow :many
+-- Retrieves the materialized trends for a window, highest score first.
+SELECT *
+FROM hashtag_trends
+WHERE
+    window_name = @window_name
+ORDER BY score DESC, current_count DESC, tag ASC
+LIMIT @max_trends;
@@ -0,0 +1,167 @@
+-- name: CreateUserList :one
+-- creates a new, empty list for the provided user
+INSERT INTO
+    user_lists (
+        created_at,
+        updated_at,
+        user_id,
+        name,
+        description,
+        is_public
+    )
+VALUES (
+        NOW(),
+        NOW(),
+        @user_id,
+        @name,
+        @description,
+        @is_public
+    ) RETURNING *;
+
+-- name: GetUserList :one
+-- Retrieves a list, unless its owner's account is pending deletion.
+SELECT *
+FROM user_lists
+WHERE
+    id = @id
+    AND user_id IN (
+        SELECT id
+        FROM users
+        WHERE
+            deleted_at IS NULL
+    );
+
+-- name: GetUserListsForUser :many
+-- Retrieves the lists owned by the provided user, in alphabetical order.
+SELECT * FROM user_lists WHERE user_id = @user_id ORDER BY name ASC;
+
+-- name: GetSubscribedUserLists :many
+-- Retrieves the public lists the provided user subscribes to, in alphabetical order.
+SELECT *
+FROM user_lists
+WHERE
+    is_public = TRUE
+    AND id IN (
+        SELECT list_id
+        FROM user_list_subscriptions
+        WHERE
+            user_list_subscriptions.user_id = @user_id
+    )
+    AND user_id IN (
+        SELECT id
+        FROM users
+        WHERE
+            deleted_at IS NULL
+    )
+ORDER BY name ASC;
+
+-- name: GetUserListCounts :many
+-- Counts the members and subscribers of each of the provided lists.
+SELECT
+    id,
+    (
+        SELECT COUNT(*)
+        FROM user_list_members
+        WHERE
+            user_list_members.list_id = user_lists.id
+    ) AS member_count,
+    (
+        SELECT COUNT(*)
+        FROM user_list_subscriptions
+        WHERE
+            user_list_subscriptions.list_id = user_lists.id
+    ) AS subscriber_count
+FROM user_lists
+WHERE
+    id = ANY (@list_ids::uuid[]);
+
+-- name: UpdateUserList :one
+-- Updates a list's name, description and visibility
+UPDATE user_lists
+SET
+    updated_at = NOW(),
+    name = @name,
+    description = @description,
+    is_public = @is_public
+WHERE
+    id = @id RETURNING *;
+
+-- name: DeleteUserList :exec
+-- Deletes a list along with its memberships and subscriptions (ON DELETE CASCADE)
+DELETE FROM user_lists WHERE id = @id;
+
+-- name: AddUserListMember :exec
+-- adds a user to a list; adding an existing member is a no-op
+INSERT INTO
+    user_list_members (list_id, user_id, created_at)
+VALUES (@list_id, @user_id, NOW()) ON CONFLICT DO NOTHING;
+
+-- name: RemoveUserListMember :execrows
+-- removes a user from a list
+DELETE FROM user_list_members
+WHERE
+    list_id = @list_id
+    AND user_id = @user_id;
+
+-- name: GetUserListMembers :many
+-- Retrieves the members of a list, most recently added first.
+SELECT *
+FROM user_list_members
+WHERE
+    list_id = @list_id
+ORDER BY created_at DESC;
+
+-- name: SubscribeToUserList :exec
+-- subscribes a user to a list; subscribing again is a no-op
+INSERT INTO
+    user_list_subscriptions (list_id, user_id, created_at)
+VALUES (@list_id, @user_id, NOW()) ON CONFLICT DO NOTHING;
+
+-- name: UnsubscribeFromUserList :execrows
+-- unsubscribes a user from a list
+DELETE FROM user_list_subscriptions
+WHERE
+    list_id = @list_id
+    AND user_id = @user_id;
+
+-- name: GetUserListTimelinePage :many
+-- Retrieves a page of chirps by the members of a list, newest first, starting after the provided (created_at, id) cursor.
+SELECT
+    id,
+    created_at,
+    updated_at,
+    body,
+    user_id,
+    deleted_at,
+    visibility,
+    publish_at
+FROM chirps
+WHERE
+    user_id IN (
+        SELECT user_list_members.user_id
+        FROM user_list_members
+        WHERE
+            user_list_members.list_id = @list_id
+    )
+    AND (created_at, id) < (
+        @before_created_at::timestamp,
+        @before_id::uuid
+    )
+    AND deleted_at IS NULL
+    AND publish_at IS NULL
+    AND user_id IN (
+        SELECT users.id
+        FROM users
+        WHERE
+            users.deleted_at IS NULL
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT @max_chirps;
+
+-- name: GetUserListSubscriptionsForUser :many
+-- Retrieves all of the provided user's list subscriptions, most recent first, e.g. for a data export.
+SELECT *
+FROM user_list_subscriptions
+WHERE
+    user_id = @user_id
+ORDER BY created_at DESC;
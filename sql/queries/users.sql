@@ -5,31 +5,121 @@ INSERT INTO
         created_at,
         updated_at,
         email,
-        hashed_password
+        hashed_password,
+        handle
     )
 VALUES (
         NOW(),
         NOW(),
         @email,
-        @hashedPassword
+        @hashedPassword,
+        sqlc.narg('handle')
     ) RETURNING *;
 
+-- name: CancelUserDeletion :one
+-- restores an account whose deletion is still within its grace period
+UPDATE users
+SET
+    updated_at = NOW(),
+    deleted_at = NULL
+WHERE
+    id = @id RETURNING *;
+
 -- name: DeleteAllUsers :exec
 -- deletes all users data in the users table
 DELETE FROM users;
 
+-- name: DeleteUserById :exec
+-- permanently deletes a user; their chirps, media, tokens and other rows are removed by ON DELETE CASCADE
+DELETE FROM users WHERE id = @id;
+
 -- name: GetUserByEmail :one
 SELECT * FROM users WHERE email = @useremail;
 
--- name: UpdateEmailAndPasswordByUserId :one
--- updates a user record with a new hashed password and email address
+-- name: GetUserById :one
+-- includes accounts pending deletion; use GetActiveUserById when looking up a user someone else wants to interact with
+SELECT * FROM users WHERE id = @id;
+
+-- name: GetActiveUserById :one
+-- looks up a user unless their account is pending deletion
+SELECT * FROM users WHERE id = @id AND deleted_at IS NULL;
+
+-- name: GetUserIDByAvatar :one
+-- looks up the (not deleted) user using a media item as their avatar, if any
+SELECT id
+FROM users
+WHERE
+    avatar_media_id = @media_id
+    AND deleted_at IS NULL
+LIMIT 1;
+
+-- name: GetUserDeletedAt :one
+-- looks up only whether (and when) a user deleted their account, e.g. before accepting one of their access tokens
+SELECT deleted_at FROM users WHERE id = @id;
+
+-- name: GetUserByHandle :one
+-- handles are unique regardless of case
+SELECT *
+FROM users
+WHERE
+    LOWER(handle) = LOWER(@handle)
+    AND deleted_at IS NULL;
+
+-- name: GetPrivateUserIDs :many
+-- Retrieves which of the provided user ids belong to private accounts.
+SELECT id
+FROM users
+WHERE
+    id = ANY (@user_ids::uuid[])
+    AND is_private;
+
+-- name: GetUserSummaries :many
+-- Retrieves the public summary fields for all of the provided user ids, e.g. for embedding chirp authors.
+SELECT id, handle, display_name, avatar_media_id
+FROM users
+WHERE
+    id = ANY (@user_ids::uuid[]);
+
+-- name: GetUsersDueForPurge :many
+-- Retrieves the ids of users whose deletion grace period ended before the provided time, oldest first.
+SELECT id
+FROM users
+WHERE
+    deleted_at < @deleted_before
+ORDER BY deleted_at ASC
+LIMIT @max_users;
+
+-- name: GetUsersByHandles :many
+-- looks up the ids of users whose handles match any of the provided (lowercased) handles
+SELECT id, handle
+FROM users
+WHERE
+    LOWER(handle) = ANY (@handles::text[])
+    AND deleted_at IS NULL;
+
+-- name: SoftDeleteUser :one
+-- marks a user's account as deleted; it is hidden immediately and purged once the grace period has passed
+UPDATE users
+SET
+    updated_at = NOW(),
+    deleted_at = NOW()
+WHERE
+    id = @id RETURNING *;
+
+-- name: UpdateUser :one
+-- updates a user's email, password and profile fields; unchanged fields are passed through with their current values
 UPDATE users
 SET
     updated_at = NOW(),
-    email = @newEmail,
-    hashed_password = @newHashedPassword
+    email = @email,
+    hashed_password = @hashed_password,
+    handle = @handle,
+    display_name = @display_name,
+    bio = @bio,
+    avatar_media_id = @avatar_media_id,
+    is_private = @is_private
 WHERE
-    id = @userId RETURNING *;
+    id = @id RETURNING *;
 
 -- name: UpgradeUserToChirpyRedById :one
 -- upgrades a user to chirpy red based on their ID by modifying the is_chirpy_field to true.
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- handle: an optional, case-insensitively unique @handle that other users can mention
+ALTER TABLE users ADD COLUMN handle TEXT;
+CREATE UNIQUE INDEX users_handle_lower_idx ON users (LOWER(handle));
+
+-- chirp_hashtags: one row per #hashtag parsed from a chirp body
+-- tag: the lowercased hashtag without the leading '#'
+-- start_offset / end_offset: rune offsets of the entity within the body (end exclusive)
+CREATE TABLE chirp_hashtags (
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    PRIMARY KEY (chirp_id, start_offset)
+);
+CREATE INDEX chirp_hashtags_tag_idx ON chirp_hashtags (tag);
+
+-- chirp_mentions: one row per @mention parsed from a chirp body that resolved to a user
+-- handle: the handle as it was written in the chirp body, without the leading '@'
+CREATE TABLE chirp_mentions (
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    handle TEXT NOT NULL,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    PRIMARY KEY (chirp_id, start_offset)
+);
+CREATE INDEX chirp_mentions_user_id_idx ON chirp_mentions (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE chirp_mentions;
+DROP TABLE chirp_hashtags;
+DROP INDEX users_handle_lower_idx;
+ALTER TABLE users DROP COLUMN handle;
+-- +goose StatementEnd
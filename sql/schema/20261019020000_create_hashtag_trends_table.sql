@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- hashtag_trends: materialized trending hashtags, rebuilt periodically by a background worker
+-- window_name: the sliding window the trend was computed over (e.g. "1h", "24h")
+-- score: velocity score comparing usage in the current window against the window before it
+-- current_count / previous_count: number of uses in the current and previous windows
+-- computed_at: when the worker computed this row
+CREATE TABLE hashtag_trends (
+    window_name TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    current_count INTEGER NOT NULL,
+    previous_count INTEGER NOT NULL,
+    computed_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (window_name, tag)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE hashtag_trends;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- media: images uploaded by users, optionally attached to one of their chirps
+-- chirp_id: NULL until the media is attached to a chirp; each media item can only be attached once
+-- position: the order of the media within its chirp (0-3)
+-- storage_key: the key the raw bytes are stored under in the blob store
+-- content_type: sniffed from the uploaded bytes, not taken from the client
+CREATE TABLE media (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    chirp_id UUID REFERENCES chirps (id) ON DELETE CASCADE,
+    position INTEGER,
+    storage_key TEXT UNIQUE NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL
+);
+CREATE INDEX media_chirp_id_idx ON media (chirp_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE media;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+-- processing_status: uploads start as 'pending' and are picked up by the media processing workers
+-- blurhash: compact placeholder clients can render while the image loads
+-- processed_at: when processing finished (successfully or not)
+ALTER TABLE media
+ADD COLUMN processing_status TEXT NOT NULL DEFAULT 'pending' CHECK (
+    processing_status IN (
+        'pending',
+        'processing',
+        'ready',
+        'failed'
+    )
+),
+ADD COLUMN blurhash TEXT,
+ADD COLUMN processed_at TIMESTAMP;
+CREATE INDEX media_processing_status_idx ON media (processing_status);
+
+-- media_thumbnails: downscaled copies of processed media, one per size
+CREATE TABLE media_thumbnails (
+    media_id UUID NOT NULL REFERENCES media (id) ON DELETE CASCADE,
+    size_name TEXT NOT NULL,
+    storage_key TEXT UNIQUE NOT NULL,
+    content_type TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    PRIMARY KEY (media_id, size_name)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE media_thumbnails;
+DROP INDEX media_processing_status_idx;
+ALTER TABLE media
+DROP COLUMN processed_at,
+DROP COLUMN blurhash,
+DROP COLUMN processing_status;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- display_name: free-form name shown alongside the handle, empty if not set
+-- bio: short free-form description, empty if not set
+-- avatar_media_id: an uploaded image used as the avatar; cleared if the media is deleted
+ALTER TABLE users
+ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+ADD COLUMN bio TEXT NOT NULL DEFAULT '',
+ADD COLUMN avatar_media_id UUID REFERENCES media (id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+DROP COLUMN avatar_media_id,
+DROP COLUMN bio,
+DROP COLUMN display_name;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- deleted_at: when the user asked for their account to be deleted; NULL for active accounts
+-- the account is hidden immediately and purged (along with everything referencing it, via ON DELETE CASCADE) once the grace period has passed
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX users_deleted_at_idx ON users (deleted_at)
+WHERE
+    deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX users_deleted_at_idx;
+ALTER TABLE users DROP COLUMN deleted_at;
+-- +goose StatementEnd
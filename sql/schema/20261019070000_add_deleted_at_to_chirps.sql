@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- deleted_at: when the chirp was deleted; NULL for live chirps
+-- deleted chirps are hidden from every read, can be restored by their author within the retention window, and are purged after it
+ALTER TABLE chirps ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX chirps_deleted_at_idx ON chirps (deleted_at)
+WHERE
+    deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX chirps_deleted_at_idx;
+ALTER TABLE chirps DROP COLUMN deleted_at;
+-- +goose StatementEnd
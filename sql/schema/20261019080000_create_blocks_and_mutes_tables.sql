@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- user_blocks: blocker_id has blocked blocked_id; neither sees the other's chirps, and the blocked user's mentions of the blocker are dropped
+CREATE TABLE user_blocks (
+    blocker_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+CREATE INDEX user_blocks_blocked_id_idx ON user_blocks (blocked_id);
+
+-- user_mutes: muter_id has muted muted_id; the muted user's chirps are left out of the muter's timeline and search results
+CREATE TABLE user_mutes (
+    muter_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    muted_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (muter_id, muted_id),
+    CHECK (muter_id <> muted_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_mutes;
+DROP TABLE user_blocks;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- is_private: only approved followers can see a private user's chirps; following them requires an approved request
+ALTER TABLE users ADD COLUMN is_private BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- follows: follower_id follows followee_id
+-- status: 'pending' while a follow request to a private account awaits approval, then 'accepted'; denied requests are deleted
+CREATE TABLE follows (
+    follower_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'accepted')),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+CREATE INDEX follows_followee_id_idx ON follows (followee_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE follows;
+ALTER TABLE users DROP COLUMN is_private;
+-- +goose StatementEnd
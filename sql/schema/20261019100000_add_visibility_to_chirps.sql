@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- visibility: who can see the chirp, on top of the author's account privacy and blocks
+--   'public': everyone; 'followers': the author's approved followers; 'mentioned': the users mentioned in the chirp;
+--   'unlisted': everyone, but left out of timelines, search and trends
+ALTER TABLE chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public' CHECK (
+    visibility IN (
+        'public',
+        'followers',
+        'mentioned',
+        'unlisted'
+    )
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE chirps DROP COLUMN visibility;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- publish_at: set while a chirp is scheduled; the chirp is hidden from every read until the scheduler publishes it and clears this column
+ALTER TABLE chirps ADD COLUMN publish_at TIMESTAMP;
+CREATE INDEX chirps_publish_at_idx ON chirps (publish_at)
+WHERE
+    publish_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX chirps_publish_at_idx;
+ALTER TABLE chirps DROP COLUMN publish_at;
+-- +goose StatementEnd
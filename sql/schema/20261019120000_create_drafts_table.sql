@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- drafts: unpublished chirps a user is still working on; publishing one deletes it and creates a chirp
+-- body: not validated or censored until the draft is published
+-- visibility: the visibility the chirp will be published with (see chirps.visibility)
+CREATE TABLE drafts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    visibility TEXT NOT NULL DEFAULT 'public' CHECK (
+        visibility IN (
+            'public',
+            'followers',
+            'mentioned',
+            'unlisted'
+        )
+    )
+);
+CREATE INDEX drafts_user_id_idx ON drafts (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE drafts;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+-- bookmarks: chirps a user has privately saved; removed when the chirp is purged, and hidden while it is deleted
+CREATE TABLE bookmarks (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+CREATE INDEX bookmarks_user_id_created_at_idx ON bookmarks (user_id, created_at, chirp_id);
+
+-- collections: named, ordered lists of chirps curated by a user
+-- is_public: public collections can be viewed by anyone; private ones only by their owner
+CREATE TABLE collections (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    is_public BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (user_id, name)
+);
+
+-- collection_chirps: the chirps in a collection
+-- position: the order of the chirp within its collection, starting at 0; removed chirps leave gaps, which don't affect the order
+CREATE TABLE collection_chirps (
+    collection_id UUID NOT NULL REFERENCES collections (id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    added_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (collection_id, chirp_id)
+);
+CREATE INDEX collection_chirps_chirp_id_idx ON collection_chirps (chirp_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE collection_chirps;
+DROP TABLE collections;
+DROP TABLE bookmarks;
+-- +goose StatementEnd
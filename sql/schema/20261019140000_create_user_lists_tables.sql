@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+-- user_lists: named lists of accounts curated by a user, read as a timeline of their members' chirps
+-- is_public: public lists can be viewed and subscribed to by anyone; private ones only by their owner
+CREATE TABLE user_lists (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    is_public BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (user_id, name)
+);
+
+-- user_list_members: the accounts in a list
+CREATE TABLE user_list_members (
+    list_id UUID NOT NULL REFERENCES user_lists (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (list_id, user_id)
+);
+CREATE INDEX user_list_members_user_id_idx ON user_list_members (user_id);
+
+-- user_list_subscriptions: users following someone else's public list
+CREATE TABLE user_list_subscriptions (
+    list_id UUID NOT NULL REFERENCES user_lists (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (list_id, user_id)
+);
+CREATE INDEX user_list_subscriptions_user_id_idx ON user_list_subscriptions (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_list_subscriptions;
+DROP TABLE user_list_members;
+DROP TABLE user_lists;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- polls: a poll attached to a chirp, a Chirpy Red feature
+-- duration_minutes: how long the poll stays open, counted from the chirp's created_at (which is its publish time for scheduled chirps)
+CREATE TABLE polls (
+    chirp_id UUID PRIMARY KEY REFERENCES chirps (id) ON DELETE CASCADE,
+    duration_minutes INTEGER NOT NULL CHECK (duration_minutes > 0)
+);
+
+-- poll_options: the 2 to 4 choices of a poll
+-- position: the order of the option within its poll, starting at 0
+CREATE TABLE poll_options (
+    chirp_id UUID NOT NULL REFERENCES polls (chirp_id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    PRIMARY KEY (chirp_id, position)
+);
+
+-- poll_votes: the primary key allows a single vote per user per poll
+CREATE TABLE poll_votes (
+    chirp_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, user_id),
+    FOREIGN KEY (chirp_id, position) REFERENCES poll_options (chirp_id, position) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE poll_votes;
+DROP TABLE poll_options;
+DROP TABLE polls;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- conversations: a private, one-to-one conversation between two users
+-- user_a_id, user_b_id: the participants, stored in order so each pair of users has at most one conversation
+-- updated_at: when the last message was sent, used to list conversations by recent activity
+CREATE TABLE conversations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_a_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    user_b_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    CHECK (user_a_id < user_b_id),
+    UNIQUE (user_a_id, user_b_id)
+);
+CREATE INDEX conversations_user_b_id_idx ON conversations (user_b_id);
+
+-- direct_messages: the messages sent in a conversation
+-- read_at: when the recipient marked the conversation as read, NULL while unread
+CREATE TABLE direct_messages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    created_at TIMESTAMP NOT NULL,
+    conversation_id UUID NOT NULL REFERENCES conversations (id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    read_at TIMESTAMP
+);
+CREATE INDEX direct_messages_conversation_id_created_at_idx ON direct_messages (conversation_id, created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE direct_messages;
+DROP TABLE conversations;
+-- +goose StatementEnd
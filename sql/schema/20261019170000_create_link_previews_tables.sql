@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+-- link_previews: a cache of the preview cards of linked pages, shared by every chirp linking to the same url
+-- fetch_status: pending -> fetching -> ready | failed; ready and failed previews are fetched again once they are stale and linked to again
+-- canonical_url: the page's own url, after redirects; the other fields come from its OpenGraph or Twitter card metadata
+CREATE TABLE link_previews (
+    url TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    fetch_status TEXT NOT NULL DEFAULT 'pending' CHECK (
+        fetch_status IN (
+            'pending',
+            'fetching',
+            'ready',
+            'failed'
+        )
+    ),
+    fetched_at TIMESTAMP,
+    canonical_url TEXT NOT NULL DEFAULT '',
+    title TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    image_url TEXT NOT NULL DEFAULT '',
+    site_name TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX link_previews_pending_idx ON link_previews (created_at) WHERE fetch_status = 'pending';
+
+-- chirp_link_previews: the link a chirp shows a preview card for, which is the first link in its body
+CREATE TABLE chirp_link_previews (
+    chirp_id UUID PRIMARY KEY REFERENCES chirps (id) ON DELETE CASCADE,
+    url TEXT NOT NULL REFERENCES link_previews (url) ON DELETE CASCADE
+);
+CREATE INDEX chirp_link_previews_url_idx ON chirp_link_previews (url);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE chirp_link_previews;
+DROP TABLE link_previews;
+-- +goose StatementEnd
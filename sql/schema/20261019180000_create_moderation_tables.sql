@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- chirp_moderation: chirps the spam pipeline flagged, which only their authors can see until a moderator approves them
+-- status: 'queued' chirps are waiting for review; 'hidden' chirps were shadow-hidden without needing review
+-- score and reasons record the verdict, e.g. 0.85 and "duplicate_body=1.00 link_density=0.50"
+CREATE TABLE chirp_moderation (
+    chirp_id UUID PRIMARY KEY REFERENCES chirps (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('queued', 'hidden')),
+    score DOUBLE PRECISION NOT NULL,
+    reasons TEXT NOT NULL
+);
+CREATE INDEX chirp_moderation_status_idx ON chirp_moderation (status, created_at);
+
+-- user_moderation: accounts the spam pipeline flagged at signup; all of their chirps are hidden from everyone else until a moderator approves them
+CREATE TABLE user_moderation (
+    user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('queued', 'hidden')),
+    score DOUBLE PRECISION NOT NULL,
+    reasons TEXT NOT NULL
+);
+CREATE INDEX user_moderation_status_idx ON user_moderation (status, created_at);
+
+-- the spam signals count recent chirps with the same body
+CREATE INDEX chirps_body_created_at_idx ON chirps (md5(body), created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX chirps_body_created_at_idx;
+DROP TABLE user_moderation;
+DROP TABLE chirp_moderation;
+-- +goose StatementEnd
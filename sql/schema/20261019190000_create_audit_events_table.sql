@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+-- audit_events: an append-only record of security-sensitive actions, such as logins, token refreshes, password changes and admin resets
+-- actor_id and target_id aren't foreign keys, so events outlive the accounts and chirps they mention
+-- actor_id: the user who acted, if known; target_id: the user or chirp acted on, if any
+-- outcome: 'success' or 'failure', e.g. for a login with the wrong password
+-- details: event-specific fields, e.g. the email address a failed login tried
+CREATE TABLE audit_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    created_at TIMESTAMP NOT NULL,
+    event_type TEXT NOT NULL,
+    outcome TEXT NOT NULL CHECK (outcome IN ('success', 'failure')),
+    actor_id UUID,
+    target_id UUID,
+    ip_address TEXT NOT NULL,
+    user_agent TEXT NOT NULL,
+    request_id TEXT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}'
+);
+CREATE INDEX audit_events_created_at_idx ON audit_events (created_at, id);
+CREATE INDEX audit_events_actor_id_idx ON audit_events (actor_id, created_at);
+CREATE INDEX audit_events_event_type_idx ON audit_events (event_type, created_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- audit events can't be changed or removed once written, not even by DELETE FROM users on reset
+CREATE FUNCTION audit_events_append_only () RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER audit_events_append_only
+BEFORE UPDATE OR DELETE ON audit_events
+FOR EACH ROW EXECUTE FUNCTION audit_events_append_only ();
+CREATE TRIGGER audit_events_no_truncate
+BEFORE TRUNCATE ON audit_events
+FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only ();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE audit_events;
+DROP FUNCTION audit_events_append_only;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- audit events used to record email addresses in details, which can't be erased along with the account that owned them;
+-- the append-only trigger is lifted just long enough to remove them.
+-- From now on details never hold personal data: a failed login for an unknown email records a keyed hash of it (email_hash) instead.
+ALTER TABLE audit_events DISABLE TRIGGER audit_events_append_only;
+UPDATE audit_events
+SET
+    details = details - 'email' - 'old_email' - 'new_email'
+WHERE
+    details ?| ARRAY['email', 'old_email', 'new_email'];
+ALTER TABLE audit_events ENABLE TRIGGER audit_events_append_only;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- the removed email addresses can't be restored
+SELECT 1;
+-- +goose StatementEnd